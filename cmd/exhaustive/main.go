@@ -0,0 +1,44 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Exhaustive reports switch statements over enums and sealed interfaces
+// which do not list all cases.
+//
+// For example, given the following types,
+//
+//	type Status int
+//
+//	const (
+//		StatusUnknown Status = iota
+//		StatusSuccess
+//		StatusFailure
+//	)
+//
+// the following switch statement
+//
+//	switch status {
+//	case StatusSuccess:
+//	}
+//
+// is reported as missing the cases StatusFailure and StatusUnknown.
+// Switch statements with a default clause are not reported.
+//
+// Usage:
+//
+//	% exhaustive [-fix] packages
+//	% go vet -vettool=$(which exhaustive) packages
+//
+// Flags:
+//
+// -fix: apply the suggested fixes, i.e. add the missing cases
+package main
+
+import (
+	"github.com/shiba6v/reftools/cmd/fillswitch/exhaustive"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(exhaustive.Analyzer)
+}
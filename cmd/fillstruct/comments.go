@@ -0,0 +1,224 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Comments added to the filled fields.
+const (
+	commentsNone = ""     // no comments
+	commentsDoc  = "doc"  // the first line of the doc or line comment of the field
+	commentsType = "type" // the type of the field
+)
+
+func validComments(comments string) bool {
+	return comments == commentsNone || comments == commentsDoc || comments == commentsType
+}
+
+// keepComments moves the comments of the existing element kv to the
+// positions of kv in the filled literal. Comments ending before kv are
+// written on the lines before it, all others at the end of the line of
+// the node they are attached to. It must be called with f.pos at the line
+// of kv and returns the number of positions added for the comments and
+// the end of kv.
+func (f *filler) keepComments(kv *ast.KeyValueExpr) (lines int) {
+	cmap := f.cmap.Filter(kv)
+	var trailing []*ast.CommentGroup
+	for _, g := range cmap[kv] {
+		if g.End() > kv.Pos() {
+			trailing = append(trailing, g)
+			continue
+		}
+		for _, c := range g.List {
+			c.Slash = f.pos
+			f.pos++
+			lines++
+		}
+		f.comments = append(f.comments, g)
+	}
+	f.fixExprPos(kv)
+	lines += f.joinEnd(kv)
+	setPos := func(groups []*ast.CommentGroup, n ast.Node) {
+		for _, g := range groups {
+			for _, c := range g.List {
+				c.Slash = lastPos(n)
+			}
+			f.comments = append(f.comments, g)
+		}
+	}
+	setPos(trailing, kv)
+	for n, groups := range cmap {
+		if n != kv {
+			setPos(groups, n)
+		}
+	}
+	return lines
+}
+
+// lastPos returns the position of the last line of the node n,
+// whose position has been fixed by fixExprPos.
+func lastPos(n ast.Node) token.Pos {
+	switch n := n.(type) {
+	case *ast.CompositeLit:
+		return n.Rbrace
+	case *ast.KeyValueExpr:
+		return lastPos(n.Value)
+	}
+	return n.Pos()
+}
+
+// commentMap returns the comments of f inside lit associated with the nodes of lit.
+// Unlike ast.NewCommentMap, a comment following the comma after an element on the
+// line of the next element, e.g. /* c */ in {a, /* c */ c}, is associated with the
+// next element. src is the content of f.
+func commentMap(fset *token.FileSet, f *ast.File, src []byte, lit *ast.CompositeLit) ast.CommentMap {
+	var comments []*ast.CommentGroup
+	for _, g := range f.Comments {
+		if lit.Pos() <= g.Pos() && g.End() <= lit.End() {
+			comments = append(comments, g)
+		}
+	}
+	cmap := ast.NewCommentMap(fset, lit, comments)
+	if len(cmap) == 0 {
+		return cmap
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	// separated reports whether a comma outside of
+	// comments lies in the source between start and end.
+	separated := func(start, end token.Pos) bool {
+		if offset(end) > len(src) {
+			return false
+		}
+		text := append([]byte(nil), src[offset(start):offset(end)]...)
+		for _, g := range comments {
+			if start <= g.Pos() && g.End() <= end {
+				for i := offset(g.Pos()); i < offset(g.End()); i++ {
+					text[i-offset(start)] = ' '
+				}
+			}
+		}
+		return bytes.IndexByte(text, ',') >= 0
+	}
+	ast.Inspect(lit, func(n ast.Node) bool {
+		l, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		for i := 1; i < len(l.Elts); i++ {
+			prev, next := l.Elts[i-1], l.Elts[i]
+			for _, g := range comments {
+				if g.Pos() >= prev.End() && g.End() <= next.Pos() &&
+					fset.Position(g.End()).Line == fset.Position(next.Pos()).Line &&
+					separated(prev.End(), g.Pos()) {
+					moveComment(cmap, g, next)
+				}
+			}
+		}
+		return true
+	})
+	return cmap
+}
+
+// moveComment associates the comment g of cmap with the node n instead.
+func moveComment(cmap ast.CommentMap, g *ast.CommentGroup, n ast.Node) {
+	for m, groups := range cmap {
+		for i, h := range groups {
+			if h != g {
+				continue
+			}
+			if m == n {
+				return
+			}
+			if groups = append(groups[:i:i], groups[i+1:]...); len(groups) > 0 {
+				cmap[m] = groups
+			} else {
+				delete(cmap, m)
+			}
+		}
+	}
+	cmap[n] = append(cmap[n], g)
+	sort.Slice(cmap[n], func(i, j int) bool { return cmap[n][i].Pos() < cmap[n][j].Pos() })
+}
+
+// addComment adds the comment requested by opts.comments for field
+// after its value, at the end of the current line, if there is one.
+func (f *filler) addComment(field *types.Var, value ast.Expr) {
+	var text string
+	switch f.opts.comments {
+	case commentsDoc:
+		text = f.opts.fieldDocs[field.Pos()]
+	case commentsType:
+		text, _ = typeString(f.pkg, f.importNames, field.Type())
+	}
+	if text == "" {
+		return
+	}
+	// The printer places comments before the tokens following them, so the
+	// comment is put after the end of value, continuing its last line.
+	f.lines += f.joinEnd(value) + 1
+	f.pos++
+	f.joined[f.pos] = true
+	f.comments = append(f.comments, &ast.CommentGroup{
+		List: []*ast.Comment{{Slash: f.pos, Text: "// " + text}},
+	})
+}
+
+// fieldDocs returns the first lines of the doc comments, or else
+// the line comments, of the struct fields declared in files,
+// keyed by the positions of the fields.
+func fieldDocs(files []*ast.File) map[token.Pos]string {
+	docs := make(map[token.Pos]string)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			st, ok := n.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			for _, field := range st.Fields.List {
+				text := field.Doc.Text()
+				if text == "" {
+					text = field.Comment.Text()
+				}
+				if i := strings.IndexByte(text, '\n'); i >= 0 {
+					text = text[:i]
+				}
+				if text == "" {
+					continue
+				}
+				if len(field.Names) == 0 {
+					docs[embeddedPos(field.Type)] = text
+				}
+				for _, name := range field.Names {
+					docs[name.Pos()] = text
+				}
+			}
+			return true
+		})
+	}
+	return docs
+}
+
+// embeddedPos returns the position of the type name of the embedded field
+// of type typ, which is the position of the field.
+func embeddedPos(typ ast.Expr) token.Pos {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return embeddedPos(t.X)
+	case *ast.IndexExpr:
+		return embeddedPos(t.X)
+	case *ast.IndexListExpr:
+		return embeddedPos(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Pos()
+	}
+	return typ.Pos()
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// wellKnown contains the expressions used for well-known types without
+// a constructor following the NewT convention. They are keyed by import
+// path and type name, prefixed with * for pointer types. %[1]s is replaced
+// by the qualifier of the package, e.g. "time.".
+var wellKnown = map[string]string{
+	"bytes.*Buffer":                         "new(%[1]sBuffer)",
+	"context.Context":                       "%[1]sBackground()",
+	"math/big.*Float":                       "%[1]sNewFloat(0)",
+	"math/big.*Int":                         "%[1]sNewInt(0)",
+	"math/big.*Rat":                         "%[1]sNewRat(0, 1)",
+	"regexp.*Regexp":                        "%[1]sMustCompile(\"\")",
+	"strings.*Builder":                      "new(%[1]sBuilder)",
+	"sync.*Mutex":                           "new(%[1]sMutex)",
+	"sync.*Once":                            "new(%[1]sOnce)",
+	"sync.*RWMutex":                         "new(%[1]sRWMutex)",
+	"sync.*WaitGroup":                       "new(%[1]sWaitGroup)",
+	"sync.Map":                              "%[1]sMap{}",
+	"sync.Mutex":                            "%[1]sMutex{}",
+	"sync.Once":                             "%[1]sOnce{}",
+	"sync.RWMutex":                          "%[1]sRWMutex{}",
+	"sync.WaitGroup":                        "%[1]sWaitGroup{}",
+	"time.*Location":                        "%[1]sUTC",
+	"time.Time":                             "%[1]sNow()",
+	"github.com/gofrs/uuid.UUID":            "%[1]sMust(%[1]sNewV4())",
+	"github.com/google/uuid.UUID":           "%[1]sNew()",
+	"github.com/satori/go.uuid.UUID":        "%[1]sNewV4()",
+	"github.com/oklog/ulid/v2.ULID":         "%[1]sMake()",
+	"github.com/shopspring/decimal.Decimal": "%[1]sZero",
+}
+
+// construct returns an expression creating a value of type typ, which is
+// a named type or a pointer to one, using a well-known expression or
+// a constructor, or nil if there is none or its package is not imported.
+// Types in visited are not constructed again to avoid cycles between
+// constructors.
+func (f *filler) construct(typ types.Type, visited []types.Type) ast.Expr {
+	if !f.opts.constructors {
+		return nil
+	}
+	key := ""
+	ptr, isPtr := typ.(*types.Pointer)
+	if isPtr {
+		key = "*"
+		typ = ptr.Elem()
+	}
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.TypeParams().Len() > 0 {
+		return nil
+	}
+	for _, t := range visited {
+		if t == named.Underlying() {
+			return nil
+		}
+	}
+	visited = append(visited, named.Underlying())
+
+	// The expressions refer to the package of T by name,
+	// so T is only constructed if the file imports it.
+	obj := named.Obj()
+	if _, ok := f.importNames[obj.Pkg().Path()]; !ok && obj.Pkg() != f.pkg {
+		return nil
+	}
+	if expr, ok := wellKnown[obj.Pkg().Path()+"."+key+obj.Name()]; ok {
+		return &ast.BasicLit{Value: fmt.Sprintf(expr, f.qualifier(obj.Pkg())), ValuePos: f.pos}
+	}
+
+	// Look for NewT, newT or New in the package of T returning exactly the type.
+	imported := isImported(f.pkg, named)
+	title := strings.ToUpper(obj.Name()[:1]) + obj.Name()[1:]
+	for _, name := range [...]string{"New" + title, "new" + title, "New"} {
+		fn, ok := obj.Pkg().Scope().Lookup(name).(*types.Func)
+		if !ok || imported && !fn.Exported() {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.TypeParams().Len() > 0 || sig.Results().Len() != 1 {
+			continue
+		}
+		// A constructor returning *T is not used for a T, since
+		// copying a value built to be shared may corrupt it.
+		want := types.Type(named)
+		if isPtr {
+			want = types.NewPointer(named)
+		}
+		if !types.Identical(sig.Results().At(0).Type(), want) {
+			continue
+		}
+		return f.constructorCall(fn, sig, visited)
+	}
+	return nil
+}
+
+// constructorCall returns a call of the constructor fn
+// with the zero values of its parameters.
+func (f *filler) constructorCall(fn *types.Func, sig *types.Signature, visited []types.Type) ast.Expr {
+	call := &ast.CallExpr{
+		Fun:    &ast.Ident{Name: objectString(f.pkg, f.importNames, fn), NamePos: f.pos},
+		Lparen: f.pos,
+	}
+	n := sig.Params().Len()
+	if sig.Variadic() {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		arg := f.zero(litInfo{typ: sig.Params().At(i).Type()}, visited)
+		if arg == nil {
+			return nil
+		}
+		call.Args = append(call.Args, arg)
+	}
+	call.Rparen = f.pos
+	return call
+}
+
+// qualifier returns the prefix qualifying the names of pkg, e.g. "time.".
+func (f *filler) qualifier(pkg *types.Package) string {
+	if pkg == f.pkg {
+		return ""
+	}
+	name := pkg.Name()
+	if n, ok := f.importNames[pkg.Path()]; ok {
+		name = n
+	}
+	if name == "." {
+		return ""
+	}
+	return name + "."
+}
@@ -7,8 +7,11 @@ package main
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/printer"
 	"go/token"
 	"go/types"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -22,28 +25,165 @@ type litInfo struct {
 	isPointer bool         // true if the literal is of a pointer type
 }
 
+// options configures how struct literals are filled.
+type options struct {
+	defaultTags  []string // struct tag keys providing default values instead of zero values, e.g. envDefault
+	constructors bool     // use constructors and well-known expressions instead of literals
+	funcBody     string   // body of function literals, see funcBodyPanic and funcBodyZero
+	depth        int      // maximum nesting depth of filled struct literals, 0 means unlimited
+	nilPointers  bool     // fill pointers to structs with nil instead of &T{...}
+	sampleSlices bool     // fill slices of structs with one sample element
+	comments     string   // comments added to filled fields, see commentsDoc and commentsType
+	unfill       bool     // remove zero-valued fields instead of filling
+
+	fieldDocs map[token.Pos]string // doc comments of struct fields by position for commentsDoc
+	overlay   map[string][]byte    // contents of modified files by filename
+}
+
 type filler struct {
 	pkg         *types.Package
 	pos         token.Pos
 	lines       int
+	joined      map[token.Pos]bool // positions continuing the line before them
 	existing    map[string]*ast.KeyValueExpr
 	first       bool
 	importNames map[string]string // import path -> import name
+	opts        options
+	depth       int                 // nesting depth of the struct literal being filled
+	cmap        ast.CommentMap      // comments of the existing elements
+	comments    []*ast.CommentGroup // comments of the filled literal
 }
 
-func zeroValue(pkg *types.Package, importNames map[string]string, lit *ast.CompositeLit, info litInfo) (ast.Expr, int) {
+// zeroValue returns the filled literal lit, along with the comments of cmap
+// attached to the existing elements, and the offsets of the lines of the
+// literal, which is printed as if each of its positions p was at offset p-1.
+func zeroValue(pkg *types.Package, importNames map[string]string, lit *ast.CompositeLit, cmap ast.CommentMap, info litInfo, opts options) (interface{}, []int) {
 	f := filler{
 		pkg:         pkg,
 		pos:         1,
 		first:       true,
+		joined:      make(map[token.Pos]bool),
 		existing:    make(map[string]*ast.KeyValueExpr),
 		importNames: importNames,
+		opts:        opts,
+		cmap:        cmap,
+	}
+	for _, e := range keyElts(lit.Elts, info.typ, cmap) {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			f.existing[kv.Key.(*ast.Ident).Name] = kv
+		}
+	}
+	expr := f.zero(info, make([]types.Type, 0, 8))
+	lines := []int{0}
+	for i := 1; i < f.lines; i++ {
+		if !f.joined[token.Pos(i+1)] {
+			lines = append(lines, i)
+		}
+	}
+	if expr == nil || len(f.comments) == 0 {
+		return expr, lines
+	}
+	sort.SliceStable(f.comments, func(i, j int) bool { return f.comments[i].Pos() < f.comments[j].Pos() })
+	return &printer.CommentedNode{Node: expr, Comments: f.comments}, lines
+}
+
+// joinEnd moves f.pos to the last position of n, whose end lies after
+// its position if its last token is longer than one byte, e.g. "abc".
+// The positions up to there continue the line of f.pos, such that
+// the printer puts the comments after n, and the number of them is
+// returned. It must be called after the position of n is fixed.
+func (f *filler) joinEnd(n ast.Node) int {
+	end := n.End() - 1
+	if end <= f.pos {
+		return 0
+	}
+	k := int(end - f.pos)
+	for ; f.pos < end; f.pos++ {
+		f.joined[f.pos+1] = true
+	}
+	return k
+}
+
+// keyElts returns the elements elts of a literal of type typ with the
+// positional values of struct literals keyed with the names of the fields
+// in declaration order, including those of the nested literals, whose
+// elements are replaced. The comments of cmap move to the keyed values.
+func keyElts(elts []ast.Expr, typ types.Type, cmap ast.CommentMap) []ast.Expr {
+	keyed := make([]ast.Expr, 0, len(elts))
+	for i, e := range elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		switch t := typ.Underlying().(type) {
+		case *types.Struct:
+			if !ok {
+				if i >= t.NumFields() {
+					continue
+				}
+				kv = &ast.KeyValueExpr{
+					Key:   &ast.Ident{Name: t.Field(i).Name(), NamePos: e.Pos()},
+					Value: e,
+				}
+				if g, ok := cmap[e]; ok {
+					cmap[kv] = g
+					delete(cmap, e)
+				}
+				e = kv
+			}
+			if field := fieldByName(t, kv.Key); field != nil {
+				keyNested(kv.Value, field.Type(), cmap)
+			}
+		case *types.Array:
+			keyNested(valueOf(e), t.Elem(), cmap)
+		case *types.Slice:
+			keyNested(valueOf(e), t.Elem(), cmap)
+		case *types.Map:
+			if ok {
+				keyNested(kv.Key, t.Key(), cmap)
+			}
+			keyNested(valueOf(e), t.Elem(), cmap)
+		}
+		keyed = append(keyed, e)
+	}
+	return keyed
+}
+
+// keyNested keys the positional values of the literal x of type typ,
+// if x is a literal or the address of one. For a pointer type typ,
+// the literal is of the element type, possibly with & elided.
+func keyNested(x ast.Expr, typ types.Type, cmap ast.CommentMap) {
+	if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		x = u.X
+	}
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	if lit, ok := x.(*ast.CompositeLit); ok {
+		lit.Elts = keyElts(lit.Elts, typ, cmap)
+	}
+}
+
+// valueOf returns the value of the element e of a literal.
+func valueOf(e ast.Expr) ast.Expr {
+	if kv, ok := e.(*ast.KeyValueExpr); ok {
+		return kv.Value
 	}
-	for _, e := range lit.Elts {
-		kv := e.(*ast.KeyValueExpr)
-		f.existing[kv.Key.(*ast.Ident).Name] = kv
+	return e
+}
+
+// ident returns an identifier at the current position.
+func (f *filler) ident(name string) *ast.Ident {
+	return &ast.Ident{Name: name, NamePos: f.pos}
+}
+
+// fieldByName returns the field of st named by key, or nil.
+func fieldByName(st *types.Struct, key ast.Expr) *types.Var {
+	if id, ok := key.(*ast.Ident); ok {
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == id.Name {
+				return st.Field(i)
+			}
+		}
 	}
-	return f.zero(info, make([]types.Type, 0, 8)), f.lines
+	return nil
 }
 
 func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
@@ -94,8 +234,10 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 			Lparen: f.pos,
 			Args: []ast.Expr{
 				&ast.ChanType{
+					Begin: f.pos,
+					Arrow: f.pos,
 					Dir:   dir,
-					Value: ast.NewIdent(valTypeName),
+					Value: f.ident(valTypeName),
 				},
 			},
 			Rparen: f.pos,
@@ -115,8 +257,8 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 			Lbrace: f.pos,
 			Type: &ast.MapType{
 				Map:   f.pos,
-				Key:   ast.NewIdent(keyTypeName),
-				Value: ast.NewIdent(valTypeName),
+				Key:   f.ident(keyTypeName),
+				Value: f.ident(valTypeName),
 			},
 		}
 		f.pos++
@@ -132,53 +274,51 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 		f.lines += 2
 		return lit
 	case *types.Signature:
-		params := make([]*ast.Field, t.Params().Len())
-		for i := 0; i < t.Params().Len(); i++ {
-			typeName, ok := typeString(f.pkg, f.importNames, t.Params().At(i).Type())
-			if !ok {
-				return nil
-			}
-			params[i] = &ast.Field{
-				Type: ast.NewIdent(typeName),
-			}
-		}
-		results := make([]*ast.Field, t.Results().Len())
-		for i := 0; i < t.Results().Len(); i++ {
-			typeName, ok := typeString(f.pkg, f.importNames, t.Results().At(i).Type())
-			if !ok {
-				return nil
-			}
-			results[i] = &ast.Field{
-				Type: ast.NewIdent(typeName),
-			}
-		}
-		return &ast.FuncLit{
-			Type: &ast.FuncType{
-				Func:    f.pos,
-				Params:  &ast.FieldList{List: params},
-				Results: &ast.FieldList{List: results},
-			},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.ExprStmt{X: ast.NewIdent(`panic("not implemented")`)},
-				},
-			},
-		}
+		return f.funcLit(t)
+
 	case *types.Slice:
 		return f.fillSequence(info, visited, t, nil)
 
 	case *types.Array:
-		return f.fillSequence(info, visited, t, &ast.BasicLit{Value: strconv.FormatInt(t.Len(), 10)})
+		return f.fillSequence(info, visited, t, &ast.BasicLit{Value: strconv.FormatInt(t.Len(), 10), ValuePos: f.pos})
 
 	case *types.Named:
+		// Constructors of pointers are handled by the pointer case.
+		if !info.isPointer {
+			if v := f.construct(t, visited); v != nil {
+				return v
+			}
+		}
+		if c := f.enumConst(t); c != nil {
+			return &ast.Ident{Name: objectString(f.pkg, f.importNames, c), NamePos: f.pos}
+		}
 		if _, ok := t.Underlying().(*types.Struct); ok {
 			info.name = t
 		}
 		info.typ = t.Underlying()
 		return f.zero(info, visited)
 
+	case *types.Alias:
+		info.typ = types.Unalias(t)
+		return f.zero(info, visited)
+
+	case *types.TypeParam:
+		// *new(T) is the zero value of any type T.
+		return &ast.StarExpr{
+			Star: f.pos,
+			X: &ast.CallExpr{
+				Fun:    &ast.Ident{Name: "new", NamePos: f.pos},
+				Lparen: f.pos,
+				Args:   []ast.Expr{&ast.Ident{Name: t.Obj().Name(), NamePos: f.pos}},
+				Rparen: f.pos,
+			},
+		}
+
 	case *types.Pointer:
-		if _, ok := t.Elem().Underlying().(*types.Struct); ok {
+		if v := f.construct(t, visited); v != nil {
+			return v
+		}
+		if _, ok := t.Elem().Underlying().(*types.Struct); ok && !f.opts.nilPointers && !f.maxDepth() {
 			info.typ = t.Elem()
 			info.isPointer = true
 			return f.zero(info, visited)
@@ -192,7 +332,7 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 			if !ok {
 				return nil
 			}
-			newlit.Type = ast.NewIdent(typeName)
+			newlit.Type = &ast.Ident{Name: typeName, NamePos: f.pos}
 			if info.isPointer {
 				newlit.Type.(*ast.Ident).Name = "&" + newlit.Type.(*ast.Ident).Name
 			}
@@ -201,7 +341,7 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 			if !ok {
 				return nil
 			}
-			newlit.Type = ast.NewIdent(typeName)
+			newlit.Type = &ast.Ident{Name: typeName, NamePos: f.pos}
 		}
 
 		for _, typ := range visited {
@@ -211,6 +351,12 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 		}
 		visited = append(visited, t)
 
+		if f.maxDepth() {
+			return newlit
+		}
+		f.depth++
+		defer func() { f.depth-- }()
+
 		first := f.first
 		f.first = false
 		lines := 0
@@ -225,17 +371,22 @@ func (f *filler) zero(info litInfo, visited []types.Type) ast.Expr {
 			if kv, ok := f.existing[field.Name()]; first && ok {
 				f.pos++
 				lines++
-				f.fixExprPos(kv)
+				lines += f.keepComments(kv)
 				newlit.Elts = append(newlit.Elts, kv)
 			} else if !ok && !imported || field.Exported() {
 				f.pos++
 				k := &ast.Ident{Name: field.Name(), NamePos: f.pos}
-				if v := f.zero(litInfo{typ: field.Type(), name: nil}, visited); v != nil {
+				v := f.tagDefault(field.Type(), t.Tag(i))
+				if v == nil {
+					v = f.zero(litInfo{typ: field.Type(), name: nil}, visited)
+				}
+				if v != nil {
 					lines++
 					newlit.Elts = append(newlit.Elts, &ast.KeyValueExpr{
 						Key:   k,
 						Value: v,
 					})
+					f.addComment(field, v)
 				} else {
 					f.pos--
 				}
@@ -269,15 +420,21 @@ func (f *filler) fillSequence(info litInfo, visited []types.Type, t sequence, le
 		lit.Type = &ast.ArrayType{
 			Lbrack: f.pos,
 			Len:    length,
-			Elt:    ast.NewIdent(typeName),
+			Elt:    f.ident(typeName),
 		}
 	}
+	var n int64
 	if arr, isArray := t.(*types.Array); isArray {
-		lit.Elts = make([]ast.Expr, 0, arr.Len())
-		for i := int64(0); i < arr.Len(); i++ {
+		n = arr.Len()
+	} else if f.opts.sampleSlices && isStruct(t.Elem(), !f.opts.nilPointers) && !f.maxDepth() {
+		n = 1 // a sample element
+	}
+	if n > 0 {
+		lit.Elts = make([]ast.Expr, 0, n)
+		for i := int64(0); i < n; i++ {
 			f.pos++
 			elemInfo := litInfo{typ: t.Elem().Underlying(), hideType: true}
-			elemInfo.name, _ = t.Elem().(*types.Named)
+			elemInfo.name, _ = types.Unalias(t.Elem()).(*types.Named)
 			if v := f.zero(elemInfo, visited); v != nil {
 				lit.Elts = append(lit.Elts, v)
 			}
@@ -295,7 +452,8 @@ func (f *filler) fixExprPos(expr ast.Expr) {
 	case nil:
 		// ignore
 	case *ast.BasicLit:
-		expr.ValuePos = f.pos
+		// Reset the end of the literal in the source as well.
+		*expr = ast.BasicLit{ValuePos: f.pos, Kind: expr.Kind, Value: expr.Value}
 	case *ast.BinaryExpr:
 		f.fixExprPos(expr.X)
 		expr.OpPos = f.pos
@@ -313,6 +471,7 @@ func (f *filler) fixExprPos(expr ast.Expr) {
 		for _, e := range expr.Elts {
 			f.pos++
 			f.fixExprPos(e)
+			f.lines += f.joinEnd(e)
 		}
 		if l := len(expr.Elts); l > 0 {
 			f.lines += l + 2
@@ -332,6 +491,7 @@ func (f *filler) fixExprPos(expr ast.Expr) {
 		expr.Rbrack = f.pos
 	case *ast.KeyValueExpr:
 		f.fixExprPos(expr.Key)
+		expr.Colon = f.pos
 		f.fixExprPos(expr.Value)
 	case *ast.ParenExpr:
 		expr.Lparen = f.pos
@@ -354,6 +514,58 @@ func (f *filler) fixExprPos(expr ast.Expr) {
 	}
 }
 
+// enumConst returns the constant of the named basic type t whose value is
+// the zero value, e.g. time.Duration(0) is filled as is, but a Status with
+// StatusUnknown = 0 as StatusUnknown. Only constants declared in the package
+// of the filled literal or in a package imported by its file are returned.
+func (f *filler) enumConst(t *types.Named) *types.Const {
+	if _, ok := t.Underlying().(*types.Basic); !ok || t.Obj().Pkg() == nil {
+		return nil
+	}
+	imported := isImported(f.pkg, t)
+	if _, ok := f.importNames[t.Obj().Pkg().Path()]; imported && !ok {
+		return nil
+	}
+	scope := t.Obj().Pkg().Scope()
+	var zero *types.Const
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || c.Name() == "_" || imported && !c.Exported() || !types.Identical(c.Type(), t) || !isZero(c.Val()) {
+			continue
+		}
+		if zero == nil || c.Pos() < zero.Pos() {
+			zero = c
+		}
+	}
+	return zero
+}
+
+func isZero(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v) == ""
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Sign(v) == 0
+	}
+	return false
+}
+
+// maxDepth reports whether the maximum nesting depth of struct literals is reached.
+func (f *filler) maxDepth() bool {
+	return f.opts.depth > 0 && f.depth >= f.opts.depth
+}
+
+// isStruct reports whether t is a struct or, if pointers is set, a pointer to a struct.
+func isStruct(t types.Type, pointers bool) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok && pointers {
+		t = p.Elem()
+	}
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
 func isImported(pkg *types.Package, n *types.Named) bool {
 	return n != nil && pkg != n.Obj().Pkg()
 }
@@ -19,6 +19,7 @@ func TestFill(t *testing.T) {
 	tests := [...]struct {
 		name string
 		src  string
+		opts options
 		want string
 	}{
 		{
@@ -121,7 +122,7 @@ type myStruct struct {
 	a: nil,
 	c: nil,
 	d: nil,
-	f: func(int) bool { panic("not implemented") },
+	f: func(i int) bool { panic("not implemented") },
 	g: []int{},
 }`,
 		},
@@ -208,7 +209,7 @@ type myStruct struct {
 	a: 0,
 	b: nil,
 	c: []integer{},
-	f: func(reader) func(int) bool { panic("not implemented") },
+	f: func(r reader) func(int) bool { panic("not implemented") },
 }`,
 		},
 		{
@@ -290,9 +291,9 @@ type myStruct struct {
 		make(<-chan struct{}),
 	},
 	d: [3]func(struct{}, interface{}) bool{
-		func(struct{}, interface{}) bool { panic("not implemented") },
-		func(struct{}, interface{}) bool { panic("not implemented") },
-		func(struct{}, interface{}) bool { panic("not implemented") },
+		func(v struct{}, v1 interface{}) bool { panic("not implemented") },
+		func(v struct{}, v1 interface{}) bool { panic("not implemented") },
+		func(v struct{}, v1 interface{}) bool { panic("not implemented") },
 	},
 	e: [2][2][]unsafe.Pointer{
 		{
@@ -458,10 +459,10 @@ type myStruct struct {
 	fset: &token.FileSet{},
 	lit: &ast.CallExpr{
 		Fun:      nil,
-		Lparen:   0,
+		Lparen:   token.NoPos,
 		Args:     []ast.Expr{},
-		Ellipsis: 0,
-		Rparen:   0,
+		Ellipsis: token.NoPos,
+		Rparen:   token.NoPos,
 	},
 	typ:  &types.Struct{},
 	name: &types.Named{},
@@ -590,7 +591,7 @@ type myStruct struct {
 		NamePos: 0,
 		Name:    "",
 		Obj: &goast.Object{
-			Kind: 0,
+			Kind: goast.Bad,
 			Name: "",
 			Decl: nil,
 			Data: nil,
@@ -601,6 +602,495 @@ type myStruct struct {
 		R: nil,
 		N: 0,
 	},
+}`,
+		}, {
+			name: "instantiated generic types",
+			src: `package p
+
+import "io"
+
+var s = myStruct{}
+
+type pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+type page[T any] struct {
+	Items []T
+	Next  *page[T]
+}
+
+type myStruct struct {
+	a pair[int, string]
+	b page[io.Reader]
+	c map[string]pair[string, []io.Reader]
+	d chan page[int]
+}`,
+			want: `myStruct{
+	a: pair[int, string]{
+		Key: 0,
+		Val: "",
+	},
+	b: page[io.Reader]{
+		Items: []io.Reader{},
+		Next:  &page[io.Reader]{},
+	},
+	c: map[string]pair[string, []io.Reader]{
+		"": {
+			Key: "",
+			Val: []io.Reader{},
+		},
+	},
+	d: make(chan page[int]),
+}`,
+		}, {
+			name: "type parameters",
+			src: `package p
+
+import "io"
+
+func fill[T any, R io.Reader]() {
+	_ = myStruct[T, R]{}
+}
+
+type pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+type myStruct[T any, R io.Reader] struct {
+	a T
+	b R
+	c *T
+	d []T
+	e pair[int, T]
+}`,
+			want: `myStruct{
+	a: *new(T),
+	b: *new(R),
+	c: nil,
+	d: []T{},
+	e: pair[int, T]{
+		Key: 0,
+		Val: *new(T),
+	},
+}`,
+		}, {
+			name: "default tags",
+			src: `package p
+
+import "time"
+
+var s = myStruct{}
+
+type port uint16
+
+type myStruct struct {
+	Host    string        ` + "`default:\"localhost\"`" + `
+	Port    port          ` + "`envDefault:\"8080\" default:\"80\"`" + `
+	Debug   bool          ` + "`default:\"true\"`" + `
+	Ratio   float64       ` + "`default:\"0.5\"`" + `
+	Timeout time.Duration ` + "`default:\"1m30s\"`" + `
+	Tags    []string      ` + "`envDefault:\"a,b\"`" + `
+	Retries int           ` + "`default:\"many\"`" + `
+	Wait    time.Duration ` + "`default:\"soon\"`" + `
+	Name    string        ` + "`json:\"name\"`" + `
+}`,
+			opts: options{defaultTags: []string{"envDefault", "default"}},
+			want: `myStruct{
+	Host:    "localhost",
+	Port:    8080,
+	Debug:   true,
+	Ratio:   0.5,
+	Timeout: 90 * time.Second,
+	Tags:    []string{"a", "b"},
+	Retries: 0,
+	Wait:    0,
+	Name:    "",
+}`,
+		}, {
+			name: "enums",
+			src: `package p
+
+import (
+	"os"
+	"reflect"
+	"time"
+)
+
+var s = myStruct{}
+
+type status int
+
+const (
+	statusActive status = iota + 1
+	statusInactive
+	statusUnknown status = 0
+)
+
+type level string
+
+const (
+	levelDebug level = "debug"
+	levelInfo  level = "info"
+)
+
+type noConsts int
+
+type myStruct struct {
+	a status
+	b level
+	c noConsts
+	d reflect.Kind
+	e map[level]status
+	f time.Duration
+	g os.FileMode
+}`,
+			want: `myStruct{
+	a: statusUnknown,
+	b: "",
+	c: 0,
+	d: reflect.Invalid,
+	e: map[level]status{
+		"": statusUnknown,
+	},
+	f: 0,
+	g: 0,
+}`,
+		}, {
+			name: "constructors",
+			src: `package p
+
+import (
+	"bytes"
+	"container/list"
+	"time"
+)
+
+var s = myStruct{}
+
+type server struct {
+	addr string
+}
+
+func newServer(addr string, opts ...int) *server { return &server{addr: addr} }
+
+type client struct {
+	s *server
+}
+
+func newClient(s *server) client { return client{s: s} }
+
+type myStruct struct {
+	a time.Time
+	b *bytes.Buffer
+	c *list.List
+	d list.List
+	e *server
+	f server
+	g client
+	h *client
+}`,
+			opts: options{constructors: true},
+			want: `myStruct{
+	a: time.Now(),
+	b: new(bytes.Buffer),
+	c: list.New(),
+	d: list.List{},
+	e: newServer(""),
+	f: server{
+		addr: "",
+	},
+	g: newClient(newServer("")),
+	h: &client{
+		s: newServer(""),
+	},
+}`,
+		}, {
+			name: "constructors of packages not imported",
+			src: `package p
+
+import "net/http/httptest"
+
+var s = myStruct{}
+
+type myStruct struct {
+	a httptest.ResponseRecorder
+}`,
+			opts: options{constructors: true},
+			want: `myStruct{
+	a: httptest.ResponseRecorder{
+		Code: 0,
+		HeaderMap: map[string][]string{
+			"": {},
+		},
+		Body:    &bytes.Buffer{},
+		Flushed: false,
+	},
+}`,
+		}, {
+			name: "function literals",
+			src: `package p
+
+import (
+	"context"
+	"io"
+)
+
+var s = myStruct{}
+
+type point struct {
+	x, y int
+}
+
+type myStruct struct {
+	a func(ctx context.Context, args ...string) (n int, err error)
+	b func(context.Context, io.Reader, *io.Reader, []byte, string, ...int) (point, bool)
+	c func([2]int) (*point, map[string]int, interface{}, [2]int)
+	d func(func())
+	e func(error) (err error)
+}`,
+			opts: options{funcBody: funcBodyZero},
+			want: `myStruct{
+	a: func(ctx context.Context, args ...string) (n int, err error) { return 0, nil },
+	b: func(ctx context.Context, r io.Reader, r1 *io.Reader, bs []byte, s string, is ...int) (point, bool) {
+		return point{}, false
+	},
+	c: func(v [2]int) (*point, map[string]int, interface{}, [2]int) { return nil, nil, nil, [2]int{} },
+	d: func(fn func()) {},
+	e: func(err1 error) (err error) { return nil },
+}`,
+		}, {
+			name: "depth limit",
+			src: `package p
+
+import "io"
+
+var s = myStruct{}
+
+type node struct {
+	name  string
+	child *node
+	inner struct {
+		r io.Reader
+	}
+}
+
+type item struct {
+	id int
+}
+
+type myStruct struct {
+	a node
+	b *item
+	c []item
+	d []*item
+	e []int
+}`,
+			opts: options{depth: 2, sampleSlices: true},
+			want: `myStruct{
+	a: node{
+		name:  "",
+		child: nil,
+		inner: struct{r io.Reader}{},
+	},
+	b: &item{
+		id: 0,
+	},
+	c: []item{
+		{
+			id: 0,
+		},
+	},
+	d: []*item{
+		{
+			id: 0,
+		},
+	},
+	e: []int{},
+}`,
+		}, {
+			name: "nil pointers and sample slice elements",
+			src: `package p
+
+import "io"
+
+var s = myStruct{}
+
+type node struct {
+	name  string
+	child *node
+	inner struct {
+		r io.Reader
+	}
+}
+
+type item struct {
+	id int
+}
+
+type myStruct struct {
+	a node
+	b *item
+	c []item
+	d []*item
+	e []int
+}`,
+			opts: options{nilPointers: true, sampleSlices: true},
+			want: `myStruct{
+	a: node{
+		name:  "",
+		child: nil,
+		inner: struct{r io.Reader}{
+			r: nil,
+		},
+	},
+	b: nil,
+	c: []item{
+		{
+			id: 0,
+		},
+	},
+	d: []*item{},
+	e: []int{},
+}`,
+		}, {
+			name: "comments",
+			src: `package p
+
+var s = myStruct{
+	// leading
+	b: 1, // trailing
+	c: inner{
+		x: true, // nested
+	},
+}
+
+type inner struct {
+	// x is a flag.
+	x bool
+	y string // y is a name.
+}
+
+type myStruct struct {
+	// a is the first field.
+	// More text.
+	a int
+	b int
+	c inner
+	d *inner
+}`,
+			opts: options{comments: commentsDoc},
+			want: `myStruct{
+	a: 0, // a is the first field.
+	// leading
+	b: 1, // trailing
+	c: inner{
+		x: true, // nested
+	},
+	d: &inner{
+		x: false, // x is a flag.
+		y: "",    // y is a name.
+	},
+}`,
+		}, {
+			name: "type comments",
+			src: `package p
+
+import "io"
+
+var s = myStruct{}
+
+type myStruct struct {
+	a int
+	b []io.Reader
+	c struct {
+		d string
+	}
+}`,
+			opts: options{comments: commentsType},
+			want: `myStruct{
+	a: 0,             // int
+	b: []io.Reader{}, // []io.Reader
+	c: struct{d string}{
+		d: "", // string
+	}, // struct{d string}
+}`,
+		}, {
+			name: "comments of composite values",
+			src: `package p
+
+var s = myStruct{}
+
+type pair struct {
+	a int    // a doc
+	b string // b doc
+}
+
+type myStruct struct {
+	// fn doc
+	fn func(int) error
+	// ok doc
+	ok bool
+	// hosts doc
+	hosts []string ` + "`default:\"a,b\"`" + `
+	// p doc
+	p *pair
+	// n doc
+	n struct {
+		f func()
+		g int // g doc
+	}
+	// last doc
+	last int
+}`,
+			opts: options{comments: commentsDoc, defaultTags: []string{"default"}},
+			want: `myStruct{
+	fn:    func(i int) error { panic("not implemented") }, // fn doc
+	ok:    false,                                          // ok doc
+	hosts: []string{"a", "b"},                             // hosts doc
+	p: &pair{
+		a: 0,  // a doc
+		b: "", // b doc
+	}, // p doc
+	n: struct{f func(); g int}{
+		f: func() { panic("not implemented") },
+		g: 0, // g doc
+	}, // n doc
+	last: 0, // last doc
+}`,
+		}, {
+			name: "unkeyed literal",
+			src: `package p
+
+var s = myStruct{1, "a" /* b */, /* c */ inner{true, 2}, []*inner{{false, 3}}}
+
+type inner struct {
+	x bool
+	y int
+}
+
+type myStruct struct {
+	a int
+	b string
+	c inner
+	d []*inner
+}`,
+			want: `myStruct{
+	a: 1,
+	b: "a" /* b */,
+	/* c */
+	c: inner{
+		x: true,
+		y: 2,
+	},
+	d: []*inner{
+		{
+			x: false,
+			y: 3,
+		},
+	},
 }`,
 		}, {
 			name: "gRPC types",
@@ -624,10 +1114,11 @@ type myStruct struct {
 	}
 
 	for _, test := range tests {
-		pkg, importNames, lit, typ := parseStruct(t, test.name, test.src)
+		pkg, importNames, lit, cmap, docs, typ := parseStruct(t, test.name, test.src)
 
 		name := types.NewNamed(types.NewTypeName(0, pkg, "myStruct", nil), typ, nil)
-		newlit, lines := zeroValue(pkg, importNames, lit, litInfo{typ: typ, name: name})
+		test.opts.fieldDocs = docs
+		newlit, lines := zeroValue(pkg, importNames, lit, cmap, litInfo{typ: typ, name: name}, test.opts)
 
 		out := printNode(t, test.name, newlit, lines)
 		if test.want != out {
@@ -636,7 +1127,7 @@ type myStruct struct {
 	}
 }
 
-func parseStruct(t *testing.T, filename, src string) (*types.Package, map[string]string, *ast.CompositeLit, *types.Struct) {
+func parseStruct(t *testing.T, filename, src string) (*types.Package, map[string]string, *ast.CompositeLit, ast.CommentMap, map[token.Pos]string, *types.Struct) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
 	if err != nil {
@@ -650,18 +1141,23 @@ func parseStruct(t *testing.T, filename, src string) (*types.Package, map[string
 	}
 
 	pkg, _ := conf.Check(f.Name.Name, fset, []*ast.File{f}, &info)
-	importNames := buildImportNameMap(f)
+	importNames := buildImportNameMap(pkg, f)
 
-	expr := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
-	return pkg, importNames, expr.(*ast.CompositeLit), info.Types[expr].Type.Underlying().(*types.Struct)
+	var lit *ast.CompositeLit
+	ast.Inspect(f, func(n ast.Node) bool {
+		if l, ok := n.(*ast.CompositeLit); ok && lit == nil {
+			lit = l
+		}
+		return lit == nil
+	})
+	cmap := commentMap(fset, f, []byte(src), lit)
+	return pkg, importNames, lit, cmap, fieldDocs([]*ast.File{f}), info.Types[lit].Type.Underlying().(*types.Struct)
 }
 
-func printNode(t *testing.T, name string, n ast.Node, lines int) string {
+func printNode(t *testing.T, name string, n interface{}, lines []int) string {
 	fset := token.NewFileSet()
-	file := fset.AddFile("", -1, lines)
-	for i := 1; i <= lines; i++ {
-		file.AddLine(i)
-	}
+	file := fset.AddFile("", -1, lines[len(lines)-1]+1)
+	file.SetLines(lines)
 
 	var buf bytes.Buffer
 	if err := format.Node(&buf, fset, n); err != nil {
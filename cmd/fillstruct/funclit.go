@@ -0,0 +1,183 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bodies of function literals filled into fields of function types.
+const (
+	funcBodyPanic = "panic" // panic("not implemented")
+	funcBodyZero  = "zero"  // return the zero values of the results
+)
+
+func validFuncBody(body string) bool {
+	return body == funcBodyPanic || body == funcBodyZero
+}
+
+// funcLit returns a function literal of the type sig. The parameters are
+// named like in sig or after their types, e.g. ctx for a context.Context.
+func (f *filler) funcLit(sig *types.Signature) ast.Expr {
+	names := paramNames(sig.Params(), sig.Results())
+	params := make([]*ast.Field, sig.Params().Len())
+	for i := 0; i < sig.Params().Len(); i++ {
+		typ := sig.Params().At(i).Type()
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			typ = typ.(*types.Slice).Elem()
+		}
+		typeName, ok := typeString(f.pkg, f.importNames, typ)
+		if !ok {
+			return nil
+		}
+		params[i] = &ast.Field{
+			Names: []*ast.Ident{f.ident(names[i])},
+			Type:  f.ident(typeName),
+		}
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			params[i].Type = &ast.Ellipsis{Ellipsis: f.pos, Elt: params[i].Type}
+		}
+	}
+
+	results := make([]*ast.Field, sig.Results().Len())
+	zeros := make([]ast.Expr, sig.Results().Len())
+	for i := 0; i < sig.Results().Len(); i++ {
+		res := sig.Results().At(i)
+		typeName, ok := typeString(f.pkg, f.importNames, res.Type())
+		if !ok {
+			return nil
+		}
+		results[i] = &ast.Field{Type: f.ident(typeName)}
+		if res.Name() != "" {
+			results[i].Names = []*ast.Ident{f.ident(res.Name())}
+		}
+		if zeros[i] = f.returnZero(res.Type()); zeros[i] == nil {
+			return nil
+		}
+	}
+
+	// All nodes are on the line of the field, such that
+	// the printer places the comment of the field after them.
+	body := &ast.BlockStmt{Lbrace: f.pos, Rbrace: f.pos}
+	switch {
+	case f.opts.funcBody == funcBodyZero && len(zeros) > 0:
+		body.List = []ast.Stmt{&ast.ReturnStmt{Return: f.pos, Results: zeros}}
+	case f.opts.funcBody != funcBodyZero:
+		body.List = []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+			Fun:    f.ident("panic"),
+			Lparen: f.pos,
+			Args:   []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("not implemented"), ValuePos: f.pos}},
+			Rparen: f.pos,
+		}}}
+	}
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Func:    f.pos,
+			Params:  &ast.FieldList{Opening: f.pos, List: params, Closing: f.pos},
+			Results: &ast.FieldList{Opening: f.pos, List: results, Closing: f.pos},
+		},
+		Body: body,
+	}
+}
+
+// returnZero returns the zero value of typ as used in a return statement,
+// i.e. without filling structs and arrays.
+func (f *filler) returnZero(typ types.Type) ast.Expr {
+	if tp, ok := typ.(*types.TypeParam); ok {
+		return f.zero(litInfo{typ: tp}, nil)
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return f.ident("false")
+		case t.Info()&types.IsString != 0:
+			return &ast.BasicLit{Kind: token.STRING, Value: `""`, ValuePos: f.pos}
+		case t.Info()&types.IsNumeric != 0:
+			return &ast.BasicLit{Kind: token.INT, Value: "0", ValuePos: f.pos}
+		case t.Kind() == types.UnsafePointer:
+			return f.ident("nil")
+		}
+		return nil
+	case *types.Struct, *types.Array:
+		typeName, ok := typeString(f.pkg, f.importNames, typ)
+		if !ok {
+			return nil
+		}
+		return &ast.CompositeLit{Type: f.ident(typeName), Lbrace: f.pos, Rbrace: f.pos}
+	}
+	return f.ident("nil")
+}
+
+// paramNames returns the names of the parameters params, synthesized
+// from their types if they are unnamed, e.g. ctx, s and s1. The
+// synthesized names differ from the names of the results.
+func paramNames(params, results *types.Tuple) []string {
+	names := make([]string, params.Len())
+	used := make(map[string]bool)
+	for i := 0; i < results.Len(); i++ {
+		if name := results.At(i).Name(); name != "" && name != "_" {
+			used[name] = true
+		}
+	}
+	for i := 0; i < params.Len(); i++ {
+		if name := params.At(i).Name(); name != "" && name != "_" {
+			names[i] = name
+			used[name] = true
+		}
+	}
+	for i := 0; i < params.Len(); i++ {
+		if names[i] != "" {
+			continue
+		}
+		base := typeParamName(params.At(i).Type())
+		name := base
+		for n := 1; used[name] || token.Lookup(name).IsKeyword(); n++ {
+			name = base + strconv.Itoa(n)
+		}
+		names[i] = name
+		used[name] = true
+	}
+	return names
+}
+
+// typeParamName returns a name for a parameter of type typ.
+func typeParamName(typ types.Type) string {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Pointer:
+		return typeParamName(t.Elem())
+	case *types.Slice:
+		return typeParamName(t.Elem()) + "s"
+	case *types.Basic:
+		return lowerInitial(t.Name())
+	case *types.Named:
+		switch {
+		case t.Obj().Pkg() == nil && t.Obj().Name() == "error":
+			return "err"
+		case t.Obj().Pkg() != nil && t.Obj().Pkg().Path() == "context" && t.Obj().Name() == "Context":
+			return "ctx"
+		}
+		return lowerInitial(t.Obj().Name())
+	case *types.TypeParam:
+		return lowerInitial(t.Obj().Name())
+	case *types.Signature:
+		return "fn"
+	case *types.Map:
+		return "m"
+	case *types.Chan:
+		return "ch"
+	}
+	return "v"
+}
+
+func lowerInitial(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r))
+}
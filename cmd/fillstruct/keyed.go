@@ -0,0 +1,116 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// insertion inserts text at an offset of a file.
+type insertion struct {
+	offset int
+	text   string
+}
+
+// keyPackages converts all unkeyed literals of struct types
+// declared in other packages in the files of pkgs into keyed
+// literals, rewrites the files and returns their names.
+func keyPackages(pkgs []*packages.Package) ([]string, error) {
+	var changed []string
+	done := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			filename := pkg.Fset.File(f.Pos()).Name()
+			if done[filename] {
+				continue
+			}
+			done[filename] = true
+
+			ins := keyLiterals(pkg.Fset, pkg.TypesInfo, pkg.Types, f)
+			if len(ins) == 0 {
+				continue
+			}
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(filename, insert(src, ins), 0644); err != nil {
+				return nil, err
+			}
+			changed = append(changed, filename)
+		}
+	}
+	return changed, nil
+}
+
+// keyLiterals returns the insertions of the field names converting
+// the unkeyed literals in f of struct types declared in other packages
+// than pkg into keyed literals, like the composites check of go vet.
+func keyLiterals(fset *token.FileSet, info *types.Info, pkg *types.Package, f *ast.File) []insertion {
+	var ins []insertion
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || len(lit.Elts) == 0 {
+			return true
+		}
+		if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+			return true
+		}
+		tv, ok := info.Types[lit]
+		if !ok {
+			return true
+		}
+		typ := types.Unalias(tv.Type)
+		if p, ok := typ.(*types.Pointer); ok {
+			typ = types.Unalias(p.Elem())
+		}
+		named, ok := typ.(*types.Named)
+		if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg() == pkg {
+			return true
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok || st.NumFields() < len(lit.Elts) {
+			return true
+		}
+		for i, e := range lit.Elts {
+			ins = append(ins, insertion{
+				offset: fset.Position(e.Pos()).Offset,
+				text:   st.Field(i).Name() + ": ",
+			})
+		}
+		return true
+	})
+	return ins
+}
+
+// insert applies the insertions ins to src. The result is formatted
+// with gofmt if src is formatted.
+func insert(src []byte, ins []insertion) []byte {
+	sort.Slice(ins, func(i, j int) bool { return ins[i].offset < ins[j].offset })
+	var buf bytes.Buffer
+	last := 0
+	for _, in := range ins {
+		buf.Write(src[last:in.offset])
+		buf.WriteString(in.text)
+		last = in.offset
+	}
+	buf.Write(src[last:])
+
+	if formatted, err := format.Source(src); err != nil || !bytes.Equal(formatted, src) {
+		return buf.Bytes()
+	}
+	if out, err := format.Source(buf.Bytes()); err == nil {
+		return out
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestKeyLiterals(t *testing.T) {
+	tests := [...]struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "imported types",
+			src: `package p
+
+import (
+	"image"
+	"io"
+)
+
+var a = image.Point{1, 2}
+
+var b = []image.Rectangle{{image.Point{0, 0}, image.Pt(1, 1)}}
+
+var c = &io.LimitedReader{nil, 5}
+`,
+			want: `package p
+
+import (
+	"image"
+	"io"
+)
+
+var a = image.Point{X: 1, Y: 2}
+
+var b = []image.Rectangle{{Min: image.Point{X: 0, Y: 0}, Max: image.Pt(1, 1)}}
+
+var c = &io.LimitedReader{R: nil, N: 5}
+`,
+		},
+		{
+			name: "local and keyed literals",
+			src: `package p
+
+import "image"
+
+type point struct{ x, y int }
+
+var a = point{1, 2}
+
+var b = image.Point{X: 1}
+
+var c = image.Point{}
+
+var d = [2]int{1, 2}
+`,
+			want: `package p
+
+import "image"
+
+type point struct{ x, y int }
+
+var a = point{1, 2}
+
+var b = image.Point{X: 1}
+
+var c = image.Point{}
+
+var d = [2]int{1, 2}
+`,
+		},
+		{
+			name: "formatting",
+			src: `package p
+
+import "image"
+
+var r = image.Rectangle{
+	image.Point{1, 2}, // min
+	image.Point{3, 4}, // max
+}
+`,
+			want: `package p
+
+import "image"
+
+var r = image.Rectangle{
+	Min: image.Point{X: 1, Y: 2}, // min
+	Max: image.Point{X: 3, Y: 4}, // max
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, test.name, test.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+		conf := types.Config{Importer: importer.Default()}
+		pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+		if err != nil {
+			t.Fatalf("%q: %v", test.name, err)
+		}
+
+		out := string(insert([]byte(test.src), keyLiterals(fset, info, pkg, f)))
+		if test.want != out {
+			t.Errorf("%q: got %v, want %v\n", test.name, out, test.want)
+		}
+	}
+}
@@ -39,9 +39,15 @@
 //
 // after applying fillstruct.
 //
+// Fields of named basic types, such as enums, are filled with the
+// constant of the type whose value is the zero value, if the package
+// of the type declares one and the file can refer to it.
+//
 // Usage:
 //
-// 	% fillstruct [-modified] -file=<filename> -offset=<byte offset> -line=<line number>
+// 	% fillstruct -keyed [-tags=<build tags>] [packages]
+//
+// 	% fillstruct [-modified] [-defaults=<tag keys>] [-constructors] [-funcbody=<body>] [-depth=<n>] [-nilptr] [-slices] [-comments=<kind>] [-unfill] -file=<filename> -offset=<byte offset> -line=<line number>
 //
 // Flags:
 //
@@ -53,6 +59,49 @@
 //
 // -line:     line number of the struct literal, optional if -offset is present
 //
+// -defaults: comma-separated list of struct tag keys, e.g. default,envDefault;
+//            the value of the first of them present in the tag of a field is
+//            used instead of the zero value, converted to the type of the field
+//
+// -constructors: fill fields of a type T or *T with a call of the function
+//            NewT or New declared in the package of T and returning exactly
+//            the type of the field, i.e. T for T and *T for *T,
+//            and fields of well-known types like time.Time with an expression
+//            such as time.Now(), instead of a literal, if the file imports
+//            the package of T
+//
+// -funcbody: body of the function literals filled into fields of function types:
+//            panic: panic("not implemented"), the default
+//            zero:  return the zero values of the results
+//            The parameters are named like in the function type or after their types.
+//
+// -depth:    maximum nesting depth of filled struct literals, deeper struct
+//            literals are left empty and pointers to structs nil; 0 means unlimited
+//
+// -nilptr:   fill pointers to structs with nil instead of &T{...}
+//
+// -slices:   fill slices of structs with one sample element instead of leaving them empty
+//
+// -comments: add a comment to each filled field:
+//            doc:  the first line of the doc comment of the field
+//            type: the type of the field
+//            Comments of the existing elements of the literal are kept.
+//
+// -unfill:   instead of filling the struct literal, remove all fields with zero
+//            values, i.e. nil, constants equal to the zero value, *new(T) and
+//            empty composite literals of the type of the field, recursively in
+//            nested literals; fields of interface types are only removed if nil
+//
+// -keyed:    instead of filling a struct literal, convert all unkeyed literals
+//            of struct types declared in other packages, as reported by go vet,
+//            into keyed literals in the packages given as arguments, which
+//            default to the package in the current directory; the files are
+//            rewritten and their names printed
+//
+//
+// The values of an unkeyed struct literal are kept as values of the fields
+// in declaration order, and so are those of the unkeyed struct literals
+// nested in them.
 //
 // If -offset as well as -line are present, then the tool first uses the
 // more specific offset information. If there was no struct literal found
@@ -70,6 +119,7 @@ import (
 	"go/format"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -91,12 +141,28 @@ func main() {
 		modified = flag.Bool("modified", false, "read an archive of modified files from stdin")
 		offset   = flag.Int("offset", 0, "byte offset of the struct literal, optional if -line is present")
 		line     = flag.Int("line", 0, "line number of the struct literal, optional if -offset is present")
+		defaults = flag.String("defaults", "", "comma-separated list of struct tag keys providing default values, e.g. default,envDefault")
+		ctors    = flag.Bool("constructors", false, "use constructors and well-known expressions instead of literals")
+		funcBody = flag.String("funcbody", funcBodyPanic, "body of filled function literals: panic or zero")
+		depth    = flag.Int("depth", 0, "maximum nesting depth of filled struct literals, 0 means unlimited")
+		nilPtr   = flag.Bool("nilptr", false, "fill pointers to structs with nil instead of &T{...}")
+		slices   = flag.Bool("slices", false, "fill slices of structs with one sample element")
+		comments = flag.String("comments", "", "comment added to each filled field: doc or type")
+		unfill   = flag.Bool("unfill", false, "remove zero-valued fields from the struct literal instead of filling it")
+		keyed    = flag.Bool("keyed", false, "convert all unkeyed literals of imported struct types in the packages given as arguments into keyed literals")
 		btags    buildutil.TagsFlag
 	)
 	flag.Var(&btags, "tags", buildutil.TagsFlagDoc)
 	flag.Parse()
 
-	if (*offset == 0 && *line == 0) || *filename == "" {
+	if *keyed {
+		if err := keyMode(flag.Args(), btags); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if (*offset == 0 && *line == 0) || *filename == "" || !validFuncBody(*funcBody) || !validComments(*comments) {
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -129,8 +195,29 @@ func main() {
 		log.Fatal(err)
 	}
 
+	opts := options{
+		constructors: *ctors,
+		funcBody:     *funcBody,
+		depth:        *depth,
+		nilPointers:  *nilPtr,
+		sampleSlices: *slices,
+		comments:     *comments,
+		unfill:       *unfill,
+		overlay:      overlay,
+	}
+	if *defaults != "" {
+		opts.defaultTags = strings.Split(*defaults, ",")
+	}
+	if opts.comments == commentsDoc {
+		var files []*ast.File
+		packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+			files = append(files, pkg.Syntax...)
+		})
+		opts.fieldDocs = fieldDocs(files)
+	}
+
 	if *offset > 0 {
-		err = byOffset(pkgs, path, *offset)
+		err = byOffset(pkgs, path, *offset, opts)
 		switch err {
 		case nil:
 			return
@@ -142,7 +229,7 @@ func main() {
 	}
 
 	if *line > 0 {
-		err = byLine(pkgs, path, *line)
+		err = byLine(pkgs, path, *line, opts)
 		switch err {
 		case nil:
 			return
@@ -154,6 +241,35 @@ func main() {
 	log.Fatal(errNotFound)
 }
 
+// keyMode converts the unkeyed literals of imported struct types in
+// the packages matching patterns and prints the names of the changed files.
+func keyMode(patterns, tags []string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	cfg := &packages.Config{
+		Mode:       packages.LoadSyntax,
+		Tests:      true,
+		BuildFlags: []string{"-tags", strings.Join(tags, ",")},
+		Env:        os.Environ(),
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return errors.New("packages contain errors")
+	}
+	files, err := keyPackages(pkgs)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Println(f)
+	}
+	return nil
+}
+
 func absPath(filename string) (string, error) {
 	eval, err := filepath.EvalSymlinks(filename)
 	if err != nil {
@@ -162,7 +278,7 @@ func absPath(filename string) (string, error) {
 	return filepath.Abs(eval)
 }
 
-func byOffset(lprog []*packages.Package, path string, offset int) error {
+func byOffset(lprog []*packages.Package, path string, offset int, opts options) error {
 	f, pkg, pos, err := findPos(lprog, path, offset)
 	if err != nil {
 		return err
@@ -176,9 +292,8 @@ func byOffset(lprog []*packages.Package, path string, offset int) error {
 	start := lprog[0].Fset.Position(lit.Pos()).Offset
 	end := lprog[0].Fset.Position(lit.End()).Offset
 
-	importNames := buildImportNameMap(f)
-	newlit, lines := zeroValue(pkg.Types, importNames, lit, litInfo)
-	out, err := prepareOutput(newlit, lines, start, end)
+	importNames := buildImportNameMap(pkg.Types, f)
+	out, err := fillOutput(pkg, f, importNames, lit, litInfo, opts, start, end)
 	if err != nil {
 		return err
 	}
@@ -207,7 +322,7 @@ func findCompositeLit(f *ast.File, info *types.Info, pos token.Pos) (*ast.Compos
 	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
 	for i, n := range path {
 		if lit, ok := n.(*ast.CompositeLit); ok {
-			linfo.name, _ = info.Types[lit].Type.(*types.Named)
+			linfo.name, _ = types.Unalias(info.Types[lit].Type).(*types.Named)
 			linfo.typ, ok = info.Types[lit].Type.Underlying().(*types.Struct)
 			if !ok {
 				return nil, linfo, errNotFound
@@ -221,7 +336,7 @@ func findCompositeLit(f *ast.File, info *types.Info, pos token.Pos) (*ast.Compos
 	return nil, linfo, errNotFound
 }
 
-func byLine(lprog []*packages.Package, path string, line int) (err error) {
+func byLine(lprog []*packages.Package, path string, line int, opts options) (err error) {
 	var f *ast.File
 	var pkg *packages.Package
 	for _, p := range lprog {
@@ -235,7 +350,7 @@ func byLine(lprog []*packages.Package, path string, line int) (err error) {
 	if f == nil || pkg == nil {
 		return fmt.Errorf("could not find file %q", path)
 	}
-	importNames := buildImportNameMap(f)
+	importNames := buildImportNameMap(pkg.Types, f)
 
 	var outs []output
 	var prev types.Type
@@ -252,7 +367,7 @@ func byLine(lprog []*packages.Package, path string, line int) (err error) {
 		}
 
 		var info litInfo
-		info.name, _ = pkg.TypesInfo.Types[lit].Type.(*types.Named)
+		info.name, _ = types.Unalias(pkg.TypesInfo.Types[lit].Type).(*types.Named)
 		info.typ, ok = pkg.TypesInfo.Types[lit].Type.Underlying().(*types.Struct)
 		if !ok {
 			prev = pkg.TypesInfo.Types[lit].Type.Underlying()
@@ -263,10 +378,9 @@ func byLine(lprog []*packages.Package, path string, line int) (err error) {
 
 		startOff := pkg.Fset.Position(lit.Pos()).Offset
 		endOff := pkg.Fset.Position(lit.End()).Offset
-		newlit, lines := zeroValue(pkg.Types, importNames, lit, info)
 
 		var out output
-		out, err = prepareOutput(newlit, lines, startOff, endOff)
+		out, err = fillOutput(pkg, f, importNames, lit, info, opts, startOff, endOff)
 		if err != nil {
 			return false
 		}
@@ -288,8 +402,27 @@ func byLine(lprog []*packages.Package, path string, line int) (err error) {
 	return json.NewEncoder(os.Stdout).Encode(outs)
 }
 
+// fillOutput returns the output replacing the literal lit of the file f between
+// the offsets start and end by the filled literal, or by the literal without
+// zero-valued fields if opts.unfill is set.
+func fillOutput(pkg *packages.Package, f *ast.File, importNames map[string]string, lit *ast.CompositeLit, info litInfo, opts options, start, end int) (output, error) {
+	filename := pkg.Fset.File(f.Pos()).Name()
+	src, ok := opts.overlay[filename]
+	if !ok {
+		var err error
+		if src, err = ioutil.ReadFile(filename); err != nil {
+			return output{}, err
+		}
+	}
+	if opts.unfill {
+		return unfill(pkg.Fset, pkg.TypesInfo, f, src, lit)
+	}
+	newlit, lines := zeroValue(pkg.Types, importNames, lit, commentMap(pkg.Fset, f, src, lit), info, opts)
+	return prepareOutput(newlit, lines, start, end)
+}
+
 func hideType(t types.Type) bool {
-	switch t.(type) {
+	switch types.Unalias(t).(type) {
 	case *types.Array:
 		return true
 	case *types.Map:
@@ -301,12 +434,24 @@ func hideType(t types.Type) bool {
 	}
 }
 
-func buildImportNameMap(f *ast.File) map[string]string {
+// buildImportNameMap returns the names of the packages
+// imported by f, a file of pkg, keyed by import path.
+func buildImportNameMap(pkg *types.Package, f *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, p := range pkg.Imports() {
+		names[p.Path()] = p.Name()
+	}
 	imports := make(map[string]string)
 	for _, i := range f.Imports {
-		if i.Name != nil && i.Name.Name != "_" {
-			path := i.Path.Value
-			imports[path[1:len(path)-1]] = i.Name.Name
+		path := i.Path.Value
+		path = path[1 : len(path)-1]
+		switch {
+		case i.Name == nil:
+			if name, ok := names[path]; ok {
+				imports[path] = name
+			}
+		case i.Name.Name != "_":
+			imports[path] = i.Name.Name
 		}
 	}
 	return imports
@@ -318,12 +463,10 @@ type output struct {
 	Code  string `json:"code"`
 }
 
-func prepareOutput(n ast.Node, lines, start, end int) (output, error) {
+func prepareOutput(n interface{}, lines []int, start, end int) (output, error) {
 	fset := token.NewFileSet()
-	file := fset.AddFile("", -1, lines)
-	for i := 1; i <= lines; i++ {
-		file.AddLine(i)
-	}
+	file := fset.AddFile("", -1, lines[len(lines)-1]+1)
+	file.SetLines(lines)
 
 	var buf bytes.Buffer
 	if err := format.Node(&buf, fset, n); err != nil {
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tagDefault returns the default value of a field of type typ given by
+// the first of the tag keys in opts.defaultTags present in tag, converted
+// to typ, or nil if there is none or it cannot be converted.
+func (f *filler) tagDefault(typ types.Type, tag string) ast.Expr {
+	for _, key := range f.opts.defaultTags {
+		if v, ok := reflect.StructTag(tag).Lookup(key); ok {
+			return f.convertDefault(typ, v)
+		}
+	}
+	return nil
+}
+
+// convertDefault converts the textual default value v into an
+// expression of type typ. Durations are written as multiples of
+// units, e.g. 90 * time.Second, slices as comma-separated lists.
+func (f *filler) convertDefault(typ types.Type, v string) ast.Expr {
+	if isDuration(typ) {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil
+		}
+		return f.durationExpr(d)
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return f.basicDefault(t, v)
+	case *types.Slice:
+		typeName, ok := typeString(f.pkg, f.importNames, t.Elem())
+		if !ok {
+			return nil
+		}
+		lit := &ast.CompositeLit{
+			Type:   &ast.ArrayType{Lbrack: f.pos, Elt: f.ident(typeName)},
+			Lbrace: f.pos,
+			Rbrace: f.pos,
+		}
+		if v == "" {
+			return lit
+		}
+		for _, s := range strings.Split(v, ",") {
+			e := f.convertDefault(t.Elem(), strings.TrimSpace(s))
+			if e == nil {
+				return nil
+			}
+			lit.Elts = append(lit.Elts, e)
+		}
+		return lit
+	}
+	return nil
+}
+
+func (f *filler) basicDefault(t *types.Basic, v string) ast.Expr {
+	info := t.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil
+		}
+		return &ast.Ident{Name: strconv.FormatBool(b), NamePos: f.pos}
+	case info&types.IsString != 0:
+		return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(v), ValuePos: f.pos}
+	case info&types.IsUnsigned != 0:
+		if _, err := strconv.ParseUint(v, 0, sizes[t.Kind()]); err != nil {
+			return nil
+		}
+		return &ast.BasicLit{Kind: token.INT, Value: v, ValuePos: f.pos}
+	case info&types.IsInteger != 0:
+		if _, err := strconv.ParseInt(v, 0, sizes[t.Kind()]); err != nil {
+			return nil
+		}
+		return &ast.BasicLit{Kind: token.INT, Value: v, ValuePos: f.pos}
+	case info&types.IsFloat != 0:
+		if _, err := strconv.ParseFloat(v, sizes[t.Kind()]); err != nil {
+			return nil
+		}
+		return &ast.BasicLit{Kind: token.FLOAT, Value: v, ValuePos: f.pos}
+	}
+	return nil
+}
+
+// sizes contains the sizes in bits of the numeric basic types.
+var sizes = map[types.BasicKind]int{
+	types.Int:     64,
+	types.Int8:    8,
+	types.Int16:   16,
+	types.Int32:   32,
+	types.Int64:   64,
+	types.Uint:    64,
+	types.Uint8:   8,
+	types.Uint16:  16,
+	types.Uint32:  32,
+	types.Uint64:  64,
+	types.Uintptr: 64,
+	types.Float32: 32,
+	types.Float64: 64,
+}
+
+func isDuration(typ types.Type) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
+
+// durationExpr returns d as a multiple of the largest unit dividing it.
+func (f *filler) durationExpr(d time.Duration) ast.Expr {
+	units := [...]struct {
+		name string
+		d    time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+		{"Nanosecond", time.Nanosecond},
+	}
+	if d == 0 {
+		return &ast.BasicLit{Kind: token.INT, Value: "0", ValuePos: f.pos}
+	}
+	qual := "time"
+	if name, ok := f.importNames["time"]; ok {
+		qual = name
+	}
+	for _, u := range units {
+		if d%u.d != 0 {
+			continue
+		}
+		unit := ast.Expr(&ast.Ident{Name: u.name, NamePos: f.pos})
+		if qual != "." {
+			unit = &ast.SelectorExpr{X: &ast.Ident{Name: qual, NamePos: f.pos}, Sel: unit.(*ast.Ident)}
+		}
+		if d == u.d {
+			return unit
+		}
+		return &ast.BinaryExpr{
+			X:     &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(int64(d/u.d), 10), ValuePos: f.pos},
+			OpPos: f.pos,
+			Op:    token.MUL,
+			Y:     unit,
+		}
+	}
+	panic("unreachable")
+}
@@ -185,25 +185,63 @@ func (w *typeWriter) writeType(typ types.Type, visited []types.Type) {
 		}
 
 	case *types.Named:
-		if isImported(w.pkg, t) && t.Obj().Pkg() != nil {
-			pkg := t.Obj().Pkg()
-			if name, ok := w.importNames[pkg.Path()]; ok {
-				if name == "." {
-					w.buf.WriteString(t.Obj().Name())
-				} else {
-					w.buf.WriteString(fmt.Sprintf("%s.%s", name, t.Obj().Name()))
-				}
+		w.writeObjectName(t.Obj())
+		w.writeTypeArgs(t.TypeArgs(), visited)
+
+	case *types.Alias:
+		w.writeObjectName(t.Obj())
+		w.writeTypeArgs(t.TypeArgs(), visited)
+
+	case *types.TypeParam:
+		w.buf.WriteString(t.Obj().Name())
+
+	default:
+		// For externally defined implementations of Type.
+		w.buf.WriteString(t.String())
+	}
+}
+
+// objectString returns the name of the package-level object obj,
+// qualified with the import name of its package if necessary.
+func objectString(pkg *types.Package, importNames map[string]string, obj types.Object) string {
+	w := typeWriter{
+		buf:         &bytes.Buffer{},
+		pkg:         pkg,
+		importNames: importNames,
+	}
+	w.writeObjectName(obj)
+	return w.buf.String()
+}
+
+func (w *typeWriter) writeObjectName(obj types.Object) {
+	if pkg := obj.Pkg(); pkg != nil && pkg != w.pkg {
+		if name, ok := w.importNames[pkg.Path()]; ok {
+			if name == "." {
+				w.buf.WriteString(obj.Name())
 			} else {
-				w.buf.WriteString(fmt.Sprintf("%s.%s", pkg.Name(), t.Obj().Name()))
+				w.buf.WriteString(fmt.Sprintf("%s.%s", name, obj.Name()))
 			}
 		} else {
-			w.buf.WriteString(t.Obj().Name())
+			w.buf.WriteString(fmt.Sprintf("%s.%s", pkg.Name(), obj.Name()))
 		}
+	} else {
+		w.buf.WriteString(obj.Name())
+	}
+}
 
-	default:
-		// For externally defined implementations of Type.
-		w.buf.WriteString(t.String())
+// writeTypeArgs writes the type arguments of an instantiated type, if any.
+func (w *typeWriter) writeTypeArgs(list *types.TypeList, visited []types.Type) {
+	if list.Len() == 0 {
+		return
+	}
+	w.buf.WriteByte('[')
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			w.buf.WriteString(", ")
+		}
+		w.writeType(list.At(i), visited)
 	}
+	w.buf.WriteByte(']')
 }
 
 func (w *typeWriter) writeTuple(tup *types.Tuple, variadic bool, visited []types.Type) {
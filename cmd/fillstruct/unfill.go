@@ -0,0 +1,228 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// span is a range of a file to delete.
+type span struct {
+	start, end token.Pos
+}
+
+// unfiller collects the elements of a literal to delete.
+type unfiller struct {
+	fset *token.FileSet
+	info *types.Info
+	file *ast.File
+}
+
+// unfill returns the output replacing lit by lit without the zero-valued
+// elements of struct literals, recursively. An element is zero-valued if its value is
+// nil, or of the type of the field and a constant equal to the zero value of
+// the type, *new(T) or an empty composite literal. src is the content of the
+// file of lit.
+func unfill(fset *token.FileSet, info *types.Info, f *ast.File, src []byte, lit *ast.CompositeLit) (output, error) {
+	u := unfiller{fset: fset, info: info, file: f}
+	spans, _ := u.lit(lit)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	start := fset.Position(lit.Pos()).Offset
+	end := fset.Position(lit.End()).Offset
+	var buf bytes.Buffer
+	last := start
+	for _, s := range spans {
+		buf.Write(src[last:fset.Position(s.start).Offset])
+		last = fset.Position(s.end).Offset
+	}
+	buf.Write(src[last:end])
+
+	// Format the literal as value of a declaration; a literal
+	// with an elided type gets the placeholder type _.
+	prefix := "package p\n\nvar _ = "
+	if lit.Type == nil {
+		prefix += "_"
+	}
+	code := buf.String()
+	if out, err := format.Source([]byte(prefix + code + "\n")); err == nil {
+		code = strings.TrimSuffix(strings.TrimPrefix(string(out), prefix), "\n")
+	}
+	return output{Start: start, End: end, Code: code}, nil
+}
+
+// lit returns the spans to delete from lit and
+// whether lit is empty after deleting them.
+func (u *unfiller) lit(lit *ast.CompositeLit) (spans []span, empty bool) {
+	var st *types.Struct
+	if t := u.info.Types[lit].Type; t != nil {
+		st, _ = t.Underlying().(*types.Struct)
+	}
+	removed := make([]bool, len(lit.Elts))
+	n := 0
+	for i, e := range lit.Elts {
+		kv, isKV := e.(*ast.KeyValueExpr)
+		if !isKV {
+			spans = append(spans, u.nested(e)...)
+			continue
+		}
+		if _, ok := kv.Key.(*ast.CompositeLit); ok {
+			spans = append(spans, u.nested(kv.Key)...)
+		}
+		var field *types.Var
+		if st != nil {
+			field = fieldByName(st, kv.Key)
+		}
+		if field == nil {
+			spans = append(spans, u.nested(kv.Value)...)
+			continue
+		}
+		nested, zero := u.zero(kv.Value, field.Type())
+		if zero {
+			removed[i] = true
+			n++
+			continue
+		}
+		spans = append(spans, nested...)
+	}
+	if n == 0 {
+		return spans, len(lit.Elts) == 0
+	}
+	if n == len(lit.Elts) {
+		return []span{{lit.Lbrace + 1, lit.Rbrace}}, true
+	}
+	return append(spans, u.elementSpans(lit, removed)...), false
+}
+
+// nested returns the spans to delete from the literals nested in e.
+func (u *unfiller) nested(e ast.Expr) []span {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.CompositeLit:
+		spans, _ := u.lit(e)
+		return spans
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return u.nested(e.X)
+		}
+	}
+	return nil
+}
+
+// zero returns the spans to delete from the literals nested in the value e
+// of a field of type typ and whether e is the zero value of typ afterwards.
+// Other than nil, a value is only the zero value if it is of type typ,
+// e.g. 0 is not for an interface type, since it makes the interface non-nil.
+func (u *unfiller) zero(e ast.Expr, typ types.Type) ([]span, bool) {
+	e = ast.Unparen(e)
+	tv := u.info.Types[e]
+	if tv.IsNil() {
+		return nil, true
+	}
+	if tv.Type == nil || !types.Identical(tv.Type, typ) {
+		return u.nested(e), false
+	}
+	if lit, ok := e.(*ast.CompositeLit); ok {
+		return u.lit(lit)
+	}
+	if tv.Value != nil && isZero(tv.Value) {
+		return nil, true
+	}
+	// *new(T)
+	if star, ok := e.(*ast.StarExpr); ok {
+		if call, ok := star.X.(*ast.CallExpr); ok && len(call.Args) == 1 {
+			if id, ok := call.Fun.(*ast.Ident); ok && u.info.Uses[id] == types.Universe.Lookup("new") {
+				return nil, true
+			}
+		}
+	}
+	return u.nested(e), false
+}
+
+// elementSpans returns the spans deleting the removed elements of lit.
+// If each element is on its own lines, the lines of the removed elements
+// are deleted along with their comments.
+func (u *unfiller) elementSpans(lit *ast.CompositeLit, removed []bool) []span {
+	var spans []span
+	if u.ownLines(lit) {
+		for i, e := range lit.Elts {
+			if removed[i] {
+				spans = append(spans, span{u.lineStart(u.docStart(lit, i)), u.lineEnd(e.End())})
+			}
+		}
+		return spans
+	}
+
+	// Delete runs of removed elements up to the next element,
+	// or from the previous element if the run is at the end.
+	for i := 0; i < len(lit.Elts); i++ {
+		if !removed[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(lit.Elts) && removed[j+1] {
+			j++
+		}
+		if j+1 < len(lit.Elts) {
+			spans = append(spans, span{lit.Elts[i].Pos(), lit.Elts[j+1].Pos()})
+		} else {
+			spans = append(spans, span{lit.Elts[i-1].End(), lit.Elts[j].End()})
+		}
+		i = j
+	}
+	return spans
+}
+
+// ownLines reports whether each element of lit starts on a new line.
+func (u *unfiller) ownLines(lit *ast.CompositeLit) bool {
+	prev := lit.Lbrace
+	for _, e := range lit.Elts {
+		if u.line(e.Pos()) == u.line(prev) {
+			return false
+		}
+		prev = e.End()
+	}
+	return u.line(lit.Rbrace) != u.line(prev)
+}
+
+// docStart returns the start of the comment directly preceding
+// the i-th element of lit, or the start of the element.
+func (u *unfiller) docStart(lit *ast.CompositeLit, i int) token.Pos {
+	start := lit.Elts[i].Pos()
+	prev := lit.Lbrace
+	if i > 0 {
+		prev = lit.Elts[i-1].End()
+	}
+	for j := len(u.file.Comments) - 1; j >= 0; j-- {
+		c := u.file.Comments[j]
+		if c.End() <= start && c.Pos() > prev && u.line(c.Pos()) > u.line(prev) && u.line(c.End()) == u.line(start)-1 {
+			start = c.Pos()
+		}
+	}
+	return start
+}
+
+func (u *unfiller) line(pos token.Pos) int {
+	return u.fset.Position(pos).Line
+}
+
+func (u *unfiller) lineStart(pos token.Pos) token.Pos {
+	tf := u.fset.File(pos)
+	return tf.LineStart(tf.Line(pos))
+}
+
+// lineEnd returns the start of the line following the line of pos.
+func (u *unfiller) lineEnd(pos token.Pos) token.Pos {
+	tf := u.fset.File(pos)
+	if l := tf.Line(pos); l < tf.LineCount() {
+		return tf.LineStart(l + 1)
+	}
+	return token.Pos(tf.Base() + tf.Size())
+}
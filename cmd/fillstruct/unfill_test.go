@@ -0,0 +1,182 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestUnfill(t *testing.T) {
+	tests := [...]struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "basic types",
+			src: `package p
+
+var s = myStruct{
+	a: 0,
+	// b is set.
+	b: 1,
+	// c is the zero value.
+	c: "",
+	d: false,
+	e: 0.0,
+	f: nil, // trailing
+	g: zero,
+	h: 'x',
+}
+
+const zero = 0
+
+type myStruct struct {
+	a, b int
+	c    string
+	d    bool
+	e    float64
+	f    *int
+	g    int
+	h    rune
+}`,
+			want: `myStruct{
+	// b is set.
+	b: 1,
+	h: 'x',
+}`,
+		},
+		{
+			name: "nested literals",
+			src: `package p
+
+import "io"
+
+var s = myStruct{
+	a: inner{x: 0, y: ""},
+	b: &inner{
+		x: 1,
+		y: "",
+	},
+	c: []inner{{x: 0}, {x: 2, y: ""}},
+	d: map[string]inner{"": {}},
+	e: []int{},
+	f: map[string]int{},
+	g: struct{ r io.Reader }{nil},
+	h: inner{x: 0, y: "a"},
+}
+
+type inner struct {
+	x int
+	y string
+}
+
+type myStruct struct {
+	a inner
+	b *inner
+	c []inner
+	d map[string]inner
+	e []int
+	f map[string]int
+	g struct{ r io.Reader }
+	h inner
+}`,
+			want: `myStruct{
+	b: &inner{
+		x: 1,
+	},
+	c: []inner{{}, {x: 2}},
+	d: map[string]inner{"": {}},
+	g: struct{ r io.Reader }{nil},
+	h: inner{y: "a"},
+}`,
+		},
+		{
+			name: "type parameters",
+			src: `package p
+
+func f[T any]() {
+	_ = myStruct[T]{a: *new(T), b: 1}
+}
+
+type myStruct[T any] struct {
+	a T
+	b int
+}`,
+			want: `myStruct[T]{b: 1}`,
+		},
+		{
+			name: "interface fields",
+			src: `package p
+
+var s = Box{Any: 0, Err: E{}, N: 0, S: "x", R: nil, V: *new(any)}
+
+type E struct{}
+
+func (E) Error() string { return "" }
+
+type Box struct {
+	Any any
+	Err error
+	N   int
+	S   string
+	R   interface{ Read([]byte) (int, error) }
+	V   any
+}`,
+			want: `Box{Any: 0, Err: E{}, S: "x"}`,
+		},
+		{
+			name: "all zero",
+			src: `package p
+
+var s = myStruct{
+	a: 0,
+	b: "",
+}
+
+type myStruct struct {
+	a int
+	b string
+}`,
+			want: `myStruct{}`,
+		},
+	}
+
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, test.name, test.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Uses:  make(map[*ast.Ident]types.Object),
+		}
+		conf := types.Config{Importer: importer.Default()}
+		if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, info); err != nil {
+			t.Fatalf("%q: %v", test.name, err)
+		}
+
+		var lit *ast.CompositeLit
+		ast.Inspect(f, func(n ast.Node) bool {
+			if l, ok := n.(*ast.CompositeLit); ok && lit == nil {
+				lit = l
+			}
+			return lit == nil
+		})
+		out, err := unfill(fset, info, f, []byte(test.src), lit)
+		if err != nil {
+			t.Fatalf("%q: %v", test.name, err)
+		}
+		if test.want != out.Code {
+			t.Errorf("%q: got %v, want %v\n", test.name, out.Code, test.want)
+		}
+	}
+}
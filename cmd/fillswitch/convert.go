@@ -0,0 +1,375 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shiba6v/reftools/cmd/fillswitch/exhaustive"
+	"golang.org/x/tools/go/packages"
+)
+
+// Conversion modes.
+const (
+	toMap    = "map"    // convert a switch returning constants into a map lookup
+	toSwitch = "switch" // convert a map lookup into a switch
+)
+
+func validConvert(mode string) bool {
+	return mode == "" || mode == toMap || mode == toSwitch
+}
+
+// convert converts the innermost switch statement (toMap) or map lookup
+// (toSwitch) for which contains returns true and writes the resulting edits.
+func convert(pkg *packages.Package, f *ast.File, contains func(ast.Node) bool, mode string, dst io.Writer) error {
+	var (
+		outs []output
+		err  error
+	)
+	switch mode {
+	case toMap:
+		outs, err = switchToMap(pkg, f, contains)
+	case toSwitch:
+		outs, err = mapToSwitch(pkg, f, contains)
+	default:
+		return fmt.Errorf("unknown conversion %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+	return writeEdits(outs, dst)
+}
+
+// writeEdits writes outs sorted in the order they must be
+// applied in, i.e. starting at the end of the file.
+func writeEdits(outs []output, dst io.Writer) error {
+	sort.Slice(outs, func(i, j int) bool { return outs[i].Start > outs[j].Start })
+	return json.NewEncoder(dst).Encode(outs)
+}
+
+// switchToMap converts
+//
+//	switch k {
+//	case A:
+//		return "a"
+//	default:
+//		return "?"
+//	}
+//
+// inside func (k Kind) String() string into
+//
+//	if v, ok := kindString[k]; ok {
+//		return v
+//	}
+//	return "?"
+//
+// and declares the map kindString after the enclosing function.
+func switchToMap(pkg *packages.Package, f *ast.File, contains func(ast.Node) bool) ([]output, error) {
+	var (
+		swtch *ast.SwitchStmt
+		fn    *ast.FuncDecl
+	)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || !contains(n) {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			fn = n
+		case *ast.SwitchStmt:
+			swtch = n
+		}
+		return true
+	})
+	if swtch == nil {
+		return nil, errNotFound
+	}
+	if swtch.Init != nil || swtch.Tag == nil {
+		return nil, errors.New("only switch statements with a tag and without init statement can be converted")
+	}
+	sig := enclosingSignature(pkg.TypesInfo, f, swtch)
+	if sig == nil || fn == nil || sig.Results().Len() != 1 {
+		return nil, errors.New("switch statement is not inside a function with a single result")
+	}
+
+	keyType := types.Unalias(pkg.TypesInfo.TypeOf(swtch.Tag))
+	values := make(map[string]bool)
+	var (
+		entries bytes.Buffer
+		dflt    ast.Expr
+	)
+	for _, stmt := range swtch.Body.List {
+		cc := stmt.(*ast.CaseClause)
+		res, err := singleReturn(pkg, cc)
+		if err != nil {
+			return nil, err
+		}
+		if cc.List == nil {
+			dflt = res
+			continue
+		}
+		if pkg.TypesInfo.Types[res].Value == nil {
+			return nil, fmt.Errorf("%s: case does not return a constant", pkg.Fset.Position(cc.Pos()))
+		}
+		for _, e := range cc.List {
+			val := pkg.TypesInfo.Types[e].Value
+			if val == nil {
+				return nil, fmt.Errorf("%s: case expression is not a constant", pkg.Fset.Position(e.Pos()))
+			}
+			values[val.ExactString()] = true
+			fmt.Fprintf(&entries, "%s: %s,\n", nodeString(pkg.Fset, e), nodeString(pkg.Fset, res))
+		}
+	}
+	if missing := missingConsts(pkg, keyType, values); len(missing) > 0 {
+		fmt.Fprintf(&entries, "// missing: %s\n", strings.Join(missing, ", "))
+	}
+
+	name := mapName(pkg.Types, keyType, fn.Name.Name)
+	decl, err := format.Source([]byte(fmt.Sprintf("var %s = map[%s]%s{\n%s}",
+		name, typeString(pkg.Types, keyType), typeString(pkg.Types, sig.Results().At(0).Type()), entries.Bytes())))
+	if err != nil {
+		return nil, err
+	}
+	lookup := fmt.Sprintf("if v, ok := %s[%s]; ok {\n\treturn v\n}", name, nodeString(pkg.Fset, swtch.Tag))
+	if dflt != nil {
+		lookup += "\nreturn " + nodeString(pkg.Fset, dflt)
+	}
+
+	fnEnd := pkg.Fset.Position(fn.End()).Offset
+	return []output{
+		{Start: pkg.Fset.Position(swtch.Pos()).Offset, End: pkg.Fset.Position(swtch.End()).Offset, Code: lookup},
+		{Start: fnEnd, End: fnEnd, Code: "\n\n" + string(decl)},
+	}, nil
+}
+
+// singleReturn returns the result of the only statement
+// of cc, which must return exactly one value.
+func singleReturn(pkg *packages.Package, cc *ast.CaseClause) (ast.Expr, error) {
+	if len(cc.Body) == 1 {
+		if ret, ok := cc.Body[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+			return ret.Results[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: case is not a single return statement", pkg.Fset.Position(cc.Pos()))
+}
+
+// mapToSwitch converts the lookups
+//
+//	return kindString[k]
+//
+//	if v, ok := kindString[k]; ok {
+//		return v
+//	}
+//
+// of a package-level map initialized with a composite literal into
+// a switch statement returning the values of the map. The former
+// returns the zero value in the default clause. The declaration of
+// the map is removed if it is not exported and not used anywhere else.
+func mapToSwitch(pkg *packages.Package, f *ast.File, contains func(ast.Node) bool) ([]output, error) {
+	var (
+		stmt   ast.Stmt
+		lookup *ast.IndexExpr
+	)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || !contains(n) {
+			return false
+		}
+		if s, ok := n.(ast.Stmt); ok {
+			if idx := mapLookup(s); idx != nil {
+				stmt, lookup = s, idx
+			}
+		}
+		return true
+	})
+	if stmt == nil {
+		return nil, errNotFound
+	}
+
+	id, ok := ast.Unparen(lookup.X).(*ast.Ident)
+	if !ok {
+		return nil, errors.New("map is not a package-level variable")
+	}
+	obj, ok := pkg.TypesInfo.Uses[id].(*types.Var)
+	if !ok || obj.Parent() != pkg.Types.Scope() {
+		return nil, errors.New("map is not a package-level variable")
+	}
+	m, ok := obj.Type().Underlying().(*types.Map)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a map", obj.Name())
+	}
+	decl, lit := mapDecl(pkg, obj)
+	if lit == nil {
+		return nil, fmt.Errorf("%s is not initialized with a composite literal", obj.Name())
+	}
+
+	values := make(map[string]bool)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "switch %s {\n", nodeString(pkg.Fset, lookup.Index))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, fmt.Errorf("%s: element is not a key-value pair", pkg.Fset.Position(elt.Pos()))
+		}
+		if val := pkg.TypesInfo.Types[kv.Key].Value; val != nil {
+			values[val.ExactString()] = true
+		}
+		fmt.Fprintf(&buf, "case %s:\n\treturn %s\n", nodeString(pkg.Fset, kv.Key), nodeString(pkg.Fset, kv.Value))
+	}
+	if missing := missingConsts(pkg, types.Unalias(m.Key()), values); len(missing) > 0 {
+		fmt.Fprintf(&buf, "// missing: %s\n", strings.Join(missing, ", "))
+	}
+	if _, ok := stmt.(*ast.ReturnStmt); ok {
+		fmt.Fprintf(&buf, "default:\n\treturn %s\n", nodeString(token.NewFileSet(), zeroValue(pkg.Types, m.Elem())))
+	}
+	buf.WriteString("}")
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	outs := []output{{
+		Start: pkg.Fset.Position(stmt.Pos()).Offset,
+		End:   pkg.Fset.Position(stmt.End()).Offset,
+		Code:  string(code),
+	}}
+	if decl != nil && pkg.Fset.File(decl.Pos()) == pkg.Fset.File(f.Pos()) && !obj.Exported() && uses(pkg.TypesInfo, obj) == 1 {
+		start := decl.Pos()
+		if decl.Doc != nil {
+			start = decl.Doc.Pos()
+		}
+		outs = append(outs, output{
+			Start: pkg.Fset.Position(start).Offset,
+			End:   pkg.Fset.Position(decl.End()).Offset,
+		})
+	}
+	return outs, nil
+}
+
+// mapLookup returns the index expression of stmt if it is a map
+// lookup of one of the forms accepted by mapToSwitch.
+func mapLookup(stmt ast.Stmt) *ast.IndexExpr {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		if len(stmt.Results) == 1 {
+			idx, _ := stmt.Results[0].(*ast.IndexExpr)
+			return idx
+		}
+	case *ast.IfStmt:
+		init, ok := stmt.Init.(*ast.AssignStmt)
+		if !ok || stmt.Else != nil || len(init.Lhs) != 2 || len(init.Rhs) != 1 || len(stmt.Body.List) != 1 {
+			return nil
+		}
+		v, _ := init.Lhs[0].(*ast.Ident)
+		ok1, _ := init.Lhs[1].(*ast.Ident)
+		cond, _ := stmt.Cond.(*ast.Ident)
+		ret, _ := stmt.Body.List[0].(*ast.ReturnStmt)
+		if v == nil || ok1 == nil || cond == nil || ret == nil || cond.Name != ok1.Name || len(ret.Results) != 1 {
+			return nil
+		}
+		if res, _ := ret.Results[0].(*ast.Ident); res == nil || res.Name != v.Name {
+			return nil
+		}
+		idx, _ := init.Rhs[0].(*ast.IndexExpr)
+		return idx
+	}
+	return nil
+}
+
+// mapDecl returns the declaration of the package-level variable obj
+// and its composite literal initializer, if it is declared alone.
+func mapDecl(pkg *packages.Package, obj *types.Var) (*ast.GenDecl, *ast.CompositeLit) {
+	for _, f := range pkg.Syntax {
+		for _, d := range f.Decls {
+			gen, ok := d.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if pkg.TypesInfo.Defs[name] != obj || i >= len(vs.Values) {
+						continue
+					}
+					lit, _ := vs.Values[i].(*ast.CompositeLit)
+					if len(gen.Specs) != 1 || len(vs.Names) != 1 {
+						gen = nil
+					}
+					return gen, lit
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func uses(info *types.Info, obj types.Object) int {
+	n := 0
+	for _, o := range info.Uses {
+		if o == obj {
+			n++
+		}
+	}
+	return n
+}
+
+// missingConsts returns the names of the constants of typ
+// whose values are not contained in values.
+func missingConsts(pkg *packages.Package, typ types.Type, values map[string]bool) []string {
+	var missing []string
+	for _, obj := range exhaustive.Consts(pkg.Fset, pkg.Syntax, pkg.Types, typ, false) {
+		if values[obj.(*types.Const).Val().ExactString()] {
+			continue
+		}
+		name := obj.Name()
+		if imported(pkg.Types, obj) {
+			name = obj.Pkg().Name() + "." + name
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// mapName returns an unused name for the map replacing a switch over
+// values of type typ in the function fn, e.g. kindString for Kind.String.
+func mapName(pkg *types.Package, typ types.Type, fn string) string {
+	prefix := "values"
+	if named, ok := typ.(*types.Named); ok {
+		prefix = lowerFirst(named.Obj().Name())
+	}
+	r, size := utf8.DecodeRuneInString(fn)
+	base := prefix + string(unicode.ToUpper(r)) + fn[size:]
+
+	name := base
+	for i := 2; pkg.Scope().Lookup(name) != nil; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func nodeString(fset *token.FileSet, x ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, x); err != nil {
+		return types.ExprString(x)
+	}
+	return buf.String()
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2017 David R. Jenni. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exhaustive
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Consts returns the constants of type typ declared in the package
+// defining typ, one for each distinct value, and, if withVars is set,
+// the package-level variables of type typ declared there.
+// Only objects visible from pkg are returned. files are the parsed
+// files of pkg, used to look up the documentation of its constants.
+func Consts(fset *token.FileSet, files []*ast.File, pkg *types.Package, typ types.Type, withVars bool) []types.Object {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+
+	var (
+		consts []*types.Const
+		vars   []types.Object
+	)
+	scope := named.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Const:
+			if visible(pkg, obj) && types.Identical(obj.Type(), typ) {
+				consts = append(consts, obj)
+			}
+		case *types.Var:
+			if withVars && visible(pkg, obj) && types.Identical(obj.Type(), typ) {
+				vars = append(vars, obj)
+			}
+		}
+	}
+
+	vars = append(vars, uniqueConsts(fset, files, consts)...)
+	sort.Sort(objsByString(vars))
+	return vars
+}
+
+// uniqueConsts returns one constant for every distinct value in consts.
+// Of several constants with the same value, the first declared one which
+// is not deprecated is chosen, e.g. StatusSuccess in:
+//
+//	const (
+//		StatusSuccess Status = iota
+//		StatusOK             = StatusSuccess
+//	)
+func uniqueConsts(fset *token.FileSet, files []*ast.File, consts []*types.Const) []types.Object {
+	sort.SliceStable(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+
+	docs := docFinder{fset: fset, files: make(map[string]*ast.File)}
+	for _, f := range files {
+		docs.files[fset.Position(f.Pos()).Filename] = f
+	}
+	byValue := make(map[string]int)
+	var uniq []types.Object
+	for _, c := range consts {
+		val := c.Val().ExactString()
+		i, ok := byValue[val]
+		if !ok {
+			byValue[val] = len(uniq)
+			uniq = append(uniq, c)
+			continue
+		}
+		if docs.deprecated(uniq[i]) && !docs.deprecated(c) {
+			uniq[i] = c
+		}
+	}
+	return uniq
+}
+
+// docFinder looks up the documentation of package-level objects in the
+// parsed files they are declared in. Files of other packages, which are
+// loaded from export data, are parsed from disk.
+type docFinder struct {
+	fset  *token.FileSet
+	files map[string]*ast.File // filename -> parsed file, nil if it could not be parsed
+}
+
+// deprecated reports whether the documentation of obj
+// contains a paragraph starting with "Deprecated: ".
+func (d docFinder) deprecated(obj types.Object) bool {
+	for _, doc := range d.docs(obj) {
+		for _, para := range strings.Split(doc.Text(), "\n\n") {
+			if strings.HasPrefix(para, "Deprecated: ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d docFinder) docs(obj types.Object) []*ast.CommentGroup {
+	filename := d.fset.Position(obj.Pos()).Filename
+	f, ok := d.files[filename]
+	if !ok {
+		f, _ = parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+		d.files[filename] = f
+	}
+	if f == nil {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for _, name := range spec.Names {
+				if name.Name != obj.Name() {
+					continue
+				}
+				docs := []*ast.CommentGroup{spec.Doc, spec.Comment}
+				if len(decl.Specs) == 1 {
+					docs = append(docs, decl.Doc)
+				}
+				return docs
+			}
+		}
+	}
+	return nil
+}
+
+// Types returns the named types declared in pkgs and visible from pkg,
+// which implement iface, either directly or through a pointer receiver.
+func Types(pkgs []*types.Package, pkg *types.Package, iface types.Type) []types.Type {
+	var typs []types.Type
+
+	err := types.Universe.Lookup("error").Type()
+	if types.AssignableTo(err, iface) {
+		typs = append(typs, err)
+	}
+
+	for _, p := range pkgs {
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() || !visible(pkg, obj) {
+				continue
+			}
+
+			t, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue // e.g. unsafe.Pointer
+			}
+			if t.TypeParams().Len() > 0 {
+				continue // generic types cannot be used without instantiation
+			}
+			// Ignore iface itself and empty interfaces.
+			if i, ok := t.Underlying().(*types.Interface); ok && (iface == i || i.NumMethods() == 0) {
+				continue
+			}
+
+			if types.AssignableTo(t, iface) {
+				typs = append(typs, t)
+			} else if p := types.NewPointer(t); types.AssignableTo(p, iface) {
+				typs = append(typs, p)
+			}
+		}
+	}
+
+	sort.Sort(typesByString(typs))
+	return typs
+}
+
+// Instances returns the instantiated generic types recorded in info whose
+// generic type is declared in one of pkgs and which implement iface, or
+// pointers to them if only the pointer types implement iface.
+func Instances(info *types.Info, pkgs []*types.Package, iface *types.Interface) []types.Type {
+	search := make(map[*types.Package]bool)
+	for _, p := range pkgs {
+		search[p] = true
+	}
+
+	var typs []types.Type
+	seen := make(map[string]bool)
+	for _, inst := range info.Instances {
+		t, ok := inst.Type.(*types.Named)
+		if !ok || !search[t.Obj().Pkg()] || seen[t.String()] || parameterized(t) {
+			continue
+		}
+		seen[t.String()] = true
+
+		if types.AssignableTo(t, iface) {
+			typs = append(typs, t)
+		} else if p := types.NewPointer(t); types.AssignableTo(p, iface) {
+			typs = append(typs, p)
+		}
+	}
+
+	sort.Sort(typesByString(typs))
+	return typs
+}
+
+// parameterized reports whether t refers to a type parameter,
+// e.g. Box[T] in the receiver of a method of Box.
+func parameterized(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if parameterized(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Pointer:
+		return parameterized(t.Elem())
+	case *types.Slice:
+		return parameterized(t.Elem())
+	case *types.Array:
+		return parameterized(t.Elem())
+	case *types.Chan:
+		return parameterized(t.Elem())
+	case *types.Map:
+		return parameterized(t.Key()) || parameterized(t.Elem())
+	}
+	return false
+}
+
+// TypeTerms returns the types of the terms in the type set of the
+// constraint of tp, without tildes, or nil if the type set is not
+// restricted to a union of types, e.g. for any or comparable.
+func TypeTerms(tp *types.TypeParam) []types.Type {
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	var typs []types.Type
+	seen := make(map[string]bool)
+	addTerms(iface, &typs, seen)
+	return typs
+}
+
+func addTerms(iface *types.Interface, typs *[]types.Type, seen map[string]bool) {
+	add := func(t types.Type) {
+		// Nested constraints, e.g. interface{ Signed | Unsigned }.
+		if i, ok := t.Underlying().(*types.Interface); ok {
+			addTerms(i, typs, seen)
+			return
+		}
+		if !seen[t.String()] {
+			seen[t.String()] = true
+			*typs = append(*typs, t)
+		}
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		switch t := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < t.Len(); j++ {
+				add(t.Term(j).Type())
+			}
+		default:
+			add(t)
+		}
+	}
+}
+
+// Sealed reports whether iface is a sealed interface, i.e. an interface
+// with an unexported method, which can only be implemented by types of
+// the package declaring the method.
+func Sealed(iface *types.Interface) bool {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() {
+			return true
+		}
+	}
+	return false
+}
+
+func visible(pkg *types.Package, obj types.Object) bool {
+	if obj.Pkg() == pkg {
+		return true
+	}
+	if !obj.Exported() {
+		return false
+	}
+
+	// Rough approximation at the "internal" rules.
+
+	path := obj.Pkg().Path()
+	i := 0
+
+	switch {
+	case strings.HasSuffix(path, "/internal"):
+		i = len(path) - len("/internal")
+	case strings.Contains(path, "/internal/"):
+		i = strings.LastIndex(path, "/internal/") + 1
+	case path == "internal", strings.HasPrefix(path, "internal/"):
+		i = 0
+	default:
+		return true
+	}
+	if i > 0 {
+		i--
+	}
+	prefix := path[:i]
+	return len(prefix) > 0 && strings.HasPrefix(pkg.Path(), prefix)
+}
+
+type typesByString []types.Type
+
+func (t typesByString) Len() int           { return len(t) }
+func (t typesByString) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t typesByString) Less(i, j int) bool { return t[i].String() < t[j].String() }
+
+type objsByString []types.Object
+
+func (o objsByString) Len() int           { return len(o) }
+func (o objsByString) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o objsByString) Less(i, j int) bool { return o[i].String() < o[j].String() }
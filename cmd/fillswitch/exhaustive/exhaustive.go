@@ -0,0 +1,240 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package exhaustive enumerates the cases of (type) switch statements
+// and defines an analyzer which reports switch statements missing some
+// of these cases.
+package exhaustive
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `check that switch statements over enums and sealed interfaces are exhaustive
+
+A switch statement over a value of a named type with declared constants
+must list every distinct value of these constants. A type switch over a
+value of a sealed interface type, i.e. an interface with an unexported
+method, must list every concrete type implementing the interface.
+Switch statements with a default clause are not checked.`
+
+// Analyzer reports non-exhaustive switch statements and suggests
+// adding the missing cases.
+var Analyzer = &analysis.Analyzer{
+	Name:     "exhaustive",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.File)(nil),
+		(*ast.SwitchStmt)(nil),
+		(*ast.TypeSwitchStmt)(nil),
+	}
+	var file *ast.File
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.File:
+			file = n
+		case *ast.SwitchStmt:
+			checkSwitch(pass, file, n)
+		case *ast.TypeSwitchStmt:
+			checkTypeSwitch(pass, file, n)
+		}
+	})
+	return nil, nil
+}
+
+func checkSwitch(pass *analysis.Pass, f *ast.File, swtch *ast.SwitchStmt) {
+	if swtch.Tag == nil || hasDefault(swtch.Body) {
+		return
+	}
+	typ := types.Unalias(pass.TypesInfo.TypeOf(swtch.Tag))
+	if _, ok := typ.(*types.Named); !ok {
+		return
+	}
+
+	values := make(map[string]bool)
+	for _, cc := range swtch.Body.List {
+		for _, e := range cc.(*ast.CaseClause).List {
+			if val := pass.TypesInfo.Types[e].Value; val != nil {
+				values[val.ExactString()] = true
+			}
+		}
+	}
+
+	q := newQualifier(pass, f)
+	var missing []string
+	for _, obj := range Consts(pass.Fset, pass.Files, pass.Pkg, typ, false) {
+		if c := obj.(*types.Const); !values[c.Val().ExactString()] {
+			missing = append(missing, objectString(c, q.qualify))
+		}
+	}
+	fixable := !q.unimported
+	report(pass, swtch, swtch.Body, types.TypeString(typ, q.qualify), missing, fixable)
+}
+
+func checkTypeSwitch(pass *analysis.Pass, f *ast.File, swtch *ast.TypeSwitchStmt) {
+	if hasDefault(swtch.Body) {
+		return
+	}
+	var x ast.Expr
+	switch stmt := swtch.Assign.(type) {
+	case *ast.AssignStmt:
+		x = stmt.Rhs[0].(*ast.TypeAssertExpr).X
+	case *ast.ExprStmt:
+		x = stmt.X.(*ast.TypeAssertExpr).X
+	default:
+		return
+	}
+	named, ok := types.Unalias(pass.TypesInfo.TypeOf(x)).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok || !Sealed(iface) {
+		return
+	}
+
+	var listed []types.Type
+	for _, cc := range swtch.Body.List {
+		for _, e := range cc.(*ast.CaseClause).List {
+			if t := pass.TypesInfo.TypeOf(e); t != nil {
+				listed = append(listed, t)
+			}
+		}
+	}
+
+	q := newQualifier(pass, f)
+	var missing []string
+	for _, t := range Types([]*types.Package{named.Obj().Pkg()}, pass.Pkg, iface) {
+		if !types.IsInterface(t) && !covered(t, listed) {
+			missing = append(missing, types.TypeString(t, q.qualify))
+		}
+	}
+	fixable := !q.unimported
+	report(pass, swtch, swtch.Body, types.TypeString(named, q.qualify), missing, fixable)
+}
+
+func hasDefault(body *ast.BlockStmt) bool {
+	for _, stmt := range body.List {
+		if cc, ok := stmt.(*ast.CaseClause); ok && cc.List == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// covered reports whether t is listed or implements a listed interface.
+func covered(t types.Type, listed []types.Type) bool {
+	for _, l := range listed {
+		if types.Identical(t, l) {
+			return true
+		}
+		if iface, ok := l.Underlying().(*types.Interface); ok && types.Implements(t, iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// report reports the missing cases of a switch statement. The missing
+// cases are only suggested as a fix if they refer to no package which
+// is not imported by the file.
+func report(pass *analysis.Pass, swtch ast.Node, body *ast.BlockStmt, typ string, missing []string, fixable bool) {
+	if len(missing) == 0 {
+		return
+	}
+	diag := analysis.Diagnostic{
+		Pos:     swtch.Pos(),
+		Message: fmt.Sprintf("missing cases in switch of type %s: %s", typ, strings.Join(missing, ", ")),
+	}
+	if fixable {
+		diag.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   "Add missing cases",
+			TextEdits: []analysis.TextEdit{addCases(pass, body, missing)},
+		}}
+	}
+	pass.Report(diag)
+}
+
+// addCases returns an edit inserting a case clause for each of the
+// given expressions at the end of body.
+func addCases(pass *analysis.Pass, body *ast.BlockStmt, exprs []string) analysis.TextEdit {
+	file := pass.Fset.File(body.Rbrace)
+	line := file.Line(body.Rbrace)
+
+	// The closing brace of a switch statement is aligned with the switch keyword.
+	var indent []byte
+	if src, err := pass.ReadFile(file.Name()); err == nil {
+		src = src[file.Offset(file.LineStart(line)):]
+		indent = src[:len(src)-len(bytes.TrimLeft(src, " \t"))]
+	}
+
+	var buf bytes.Buffer
+	if file.Line(body.Lbrace) == line {
+		buf.WriteByte('\n')
+		buf.Write(indent)
+	}
+	for _, e := range exprs {
+		fmt.Fprintf(&buf, "case %s:\n", e)
+		buf.Write(indent)
+	}
+	return analysis.TextEdit{Pos: body.Rbrace, End: body.Rbrace, NewText: buf.Bytes()}
+}
+
+// A qualifier qualifies package-level objects with the
+// name under which their package is imported in a file.
+type qualifier struct {
+	pkg   *types.Package
+	names map[string]string // import path -> import name
+
+	// unimported is set once an object of a package
+	// not imported by the file has been qualified.
+	unimported bool
+}
+
+func newQualifier(pass *analysis.Pass, f *ast.File) *qualifier {
+	names := make(map[string]string)
+	for _, imp := range f.Imports {
+		if pn := pass.TypesInfo.PkgNameOf(imp); pn != nil && pn.Name() != "_" {
+			names[pn.Imported().Path()] = pn.Name()
+		}
+	}
+	return &qualifier{pkg: pass.Pkg, names: names}
+}
+
+func (q *qualifier) qualify(p *types.Package) string {
+	if p == q.pkg {
+		return ""
+	}
+	name, ok := q.names[p.Path()]
+	if !ok {
+		q.unimported = true
+		return p.Name()
+	}
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+func objectString(obj types.Object, qual types.Qualifier) string {
+	if q := qual(obj.Pkg()); q != "" {
+		return q + "." + obj.Name()
+	}
+	return obj.Name()
+}
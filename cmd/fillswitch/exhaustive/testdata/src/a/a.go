@@ -0,0 +1,68 @@
+package a
+
+import goast "go/ast"
+
+type Status int
+
+const (
+	StatusUnknown Status = iota
+	StatusSuccess
+	StatusFailure
+
+	StatusOK = StatusSuccess
+)
+
+type Shape interface {
+	area() float64
+}
+
+type Circle struct{}
+
+func (Circle) area() float64 { return 0 }
+
+type Square struct{}
+
+func (*Square) area() float64 { return 0 }
+
+type Polygon interface {
+	Shape
+	Corners() int
+}
+
+func statuses(s Status, kind goast.ObjKind) {
+	switch s { // want "missing cases in switch of type Status: StatusFailure, StatusUnknown"
+	case StatusOK:
+	}
+
+	switch s {
+	case StatusUnknown, StatusSuccess, StatusFailure:
+	}
+
+	switch s {
+	case StatusFailure:
+	default:
+	}
+
+	switch kind {} // want "missing cases in switch of type goast.ObjKind: goast.Bad, goast.Con, goast.Fun, goast.Lbl, goast.Pkg, goast.Typ, goast.Var"
+
+	switch n := 1; n {
+	case 2:
+	}
+}
+
+func shapes(s Shape, err error) {
+	switch s.(type) { // want "missing cases in switch of type Shape: \\*Square"
+	case Circle:
+	}
+
+	switch s := s.(type) {
+	case Circle:
+	case Polygon:
+	case *Square:
+		_ = s
+	}
+
+	switch err.(type) {
+	case nil:
+	}
+}
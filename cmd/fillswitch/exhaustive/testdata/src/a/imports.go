@@ -0,0 +1,8 @@
+package a
+
+import "b"
+
+func favorite() {
+	switch b.Favorite() { // want "missing cases in switch of type c.Color: c.Green, c.Red"
+	}
+}
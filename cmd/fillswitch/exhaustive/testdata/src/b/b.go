@@ -0,0 +1,5 @@
+package b
+
+import "c"
+
+func Favorite() c.Color { return c.Red }
@@ -0,0 +1,8 @@
+package c
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
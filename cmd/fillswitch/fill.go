@@ -5,167 +5,260 @@
 package main
 
 import (
+	"fmt"
 	"go/ast"
 	"go/types"
-	"sort"
+	"strconv"
 	"strings"
 
-	"golang.org/x/tools/go/loader"
+	"github.com/shiba6v/reftools/cmd/fillswitch/exhaustive"
+	"golang.org/x/tools/go/packages"
 )
 
-func fillSwitch(pkg *loader.PackageInfo, lprog *loader.Program, swtch ast.Stmt, typ types.Type) ast.Stmt {
+// options configures how switch statements are filled.
+type options struct {
+	vars        bool   // add cases for package-level variables of the tag type
+	deps        bool   // look for types implementing open interfaces outside of the main module
+	body        string // template for the bodies of added cases, see bodyPanic etc.
+	withDefault bool   // add a default clause panicking with the unhandled value
+	convert     string // convert between switch statements and map lookups instead, see toMap and toSwitch
+	gen         string // comma-separated code to generate for the enum type instead, see genString etc.
+	names       string // transformation of constant names in generated code, see namesSnake etc.
+	trimPrefix  string // prefix trimmed from constant names in generated code
+	reorder     bool   // sort the cases by declaration and remove stale and duplicate ones
+}
+
+// fillSwitch fills swtch and records the comments and imports
+// required by the added code in add.
+func fillSwitch(pkg *packages.Package, f *ast.File, swtch ast.Stmt, typ types.Type, opts options, add *additions) (ast.Stmt, error) {
+	if sel, ok := swtch.(*ast.SelectStmt); ok {
+		return fillSelect(pkg, sel), nil
+	}
 	// Do not try to fill an empty switch statement (with no tag expression and therefore typ == nil).
 	if typ == nil {
-		return swtch
+		return swtch, nil
 	}
+	typ = types.Unalias(typ)
 
 	switch swtch := swtch.(type) {
 	case *ast.SwitchStmt:
+		if t, ok := typ.(*types.Basic); ok {
+			if t.Kind() == types.Invalid {
+				return swtch, nil
+			}
+			return nil, fmt.Errorf("cannot fill switch over values of unnamed type %s", t)
+		}
 		existing := make(map[string]bool)
+		values := make(map[string]bool) // constant values of the existing cases
 		// Don't add the identifier we switch over to the case statements.
 		if id, ok := swtch.Tag.(*ast.Ident); ok {
 			existing[id.Name] = true
 		}
 		for _, cc := range swtch.Body.List {
 			for _, e := range cc.(*ast.CaseClause).List {
-				existing[typeString(pkg.Pkg, pkg.Info.TypeOf(e))] = true
+				existing[types.ExprString(e)] = true
+				if val := pkg.TypesInfo.Types[e].Value; val != nil {
+					values[val.ExactString()] = true
+				}
 			}
 		}
-		for _, v := range findConstsAndVars(lprog, pkg.Pkg, typ) {
+		added := len(swtch.Body.List)
+		keys := make(map[ast.Expr]caseKey) // keys of the added case expressions
+		for _, v := range exhaustive.Consts(pkg.Fset, pkg.Syntax, pkg.Types, typ, opts.vars) {
 			name := ast.NewIdent(v.Name())
-			if imported(pkg.Pkg, v) {
+			if imported(pkg.Types, v) {
 				name = ast.NewIdent(v.Pkg().Name() + "." + v.Name())
 			}
-			if !existing[v.Name()] {
+			id := name.Name
+			if c, ok := v.(*types.Const); ok {
+				if values[c.Val().ExactString()] {
+					continue
+				}
+				id = c.Val().ExactString()
+			}
+			if !existing[name.Name] {
 				swtch.Body.List = append(swtch.Body.List, &ast.CaseClause{
 					List: []ast.Expr{name},
 				})
+				keys[name] = objKey(v, id)
 			}
 		}
-		return swtch
+		if err := fillBodies(pkg, f, swtch, swtch.Body, added, swtch.Tag, opts, add); err != nil {
+			return nil, err
+		}
+		if opts.reorder {
+			if err := reorderCases(swtch.Body, func(e ast.Expr) caseKey {
+				if k, ok := keys[e]; ok {
+					return k
+				}
+				return exprKey(pkg, e, false)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return swtch, nil
 
 	case *ast.TypeSwitchStmt:
-		iface, ok := typ.Underlying().(*types.Interface)
-		if !ok {
-			return swtch
+		var typs []types.Type
+		if tp := typeParam(pkg.TypesInfo, typeSwitchX(swtch)); tp != nil {
+			// A type switch over any(x), where x is of type parameter type.
+			typs = exhaustive.TypeTerms(tp)
 		}
+		if typs == nil {
+			iface, ok := typ.Underlying().(*types.Interface)
+			if !ok {
+				return swtch, nil
+			}
+			pkgs := searchPackages(pkg, f, typ, iface, opts.deps)
+			typs = append(exhaustive.Types(pkgs, pkg.Types, iface), exhaustive.Instances(pkg.TypesInfo, pkgs, iface)...)
+		}
+
 		existing := make(map[string]bool)
 		for _, cc := range swtch.Body.List {
 			for _, e := range cc.(*ast.CaseClause).List {
-				name := typeString(pkg.Pkg, pkg.Info.TypeOf(e))
+				name := typeString(pkg.Types, pkg.TypesInfo.TypeOf(e))
 				existing[name] = true
 			}
 		}
-		for _, t := range findTypes(lprog, pkg.Pkg, iface) {
-			if ts := typeString(pkg.Pkg, t); !existing[ts] {
+		added := len(swtch.Body.List)
+		keys := make(map[ast.Expr]caseKey) // keys of the added case expressions
+		for _, t := range typs {
+			if ts := typeString(pkg.Types, t); !existing[ts] {
+				name := ast.NewIdent(ts)
 				swtch.Body.List = append(swtch.Body.List, &ast.CaseClause{
-					List: []ast.Expr{ast.NewIdent(ts)},
+					List: []ast.Expr{name},
 				})
+				keys[name] = typeKey(pkg.Types, t)
 			}
 		}
-		return swtch
+		if err := fillBodies(pkg, f, swtch, swtch.Body, added, typeSwitchValue(swtch), opts, add); err != nil {
+			return nil, err
+		}
+		if opts.reorder {
+			if err := reorderCases(swtch.Body, func(e ast.Expr) caseKey {
+				if k, ok := keys[e]; ok {
+					return k
+				}
+				return exprKey(pkg, e, true)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return swtch, nil
 
 	default:
 		panic("unreachable")
 	}
 }
 
-func findConstsAndVars(lprog *loader.Program, pkg *types.Package, typ types.Type) []types.Object {
-	var vars []types.Object
-	for _, info := range lprog.AllPackages {
-		for _, obj := range info.Defs {
-			switch obj := obj.(type) {
-			case *types.Const:
-				if visible(pkg, obj) && types.AssignableTo(obj.Type(), typ) {
-					vars = append(vars, obj)
-				}
-			case *types.Var:
-				if visible(pkg, obj) && !obj.IsField() && types.AssignableTo(obj.Type(), typ) {
-					vars = append(vars, obj)
-				}
-			}
-		}
+// typeSwitchValue returns the expression to refer
+// to the value switched over in the case clauses.
+func typeSwitchValue(swtch *ast.TypeSwitchStmt) ast.Expr {
+	if stmt, ok := swtch.Assign.(*ast.AssignStmt); ok {
+		return stmt.Lhs[0]
 	}
-
-	sort.Sort(objsByString(vars))
-	return vars
+	return typeSwitchX(swtch)
 }
 
-func findTypes(lprog *loader.Program, pkg *types.Package, iface types.Type) []types.Type {
-	var typs []types.Type
-
-	err := types.Universe.Lookup("error").Type()
-	if types.AssignableTo(err, iface) {
-		typs = append(typs, err)
+// typeSwitchX returns the expression x of the type switch guard x.(type).
+func typeSwitchX(swtch *ast.TypeSwitchStmt) ast.Expr {
+	switch stmt := swtch.Assign.(type) {
+	case *ast.AssignStmt:
+		return stmt.Rhs[0].(*ast.TypeAssertExpr).X
+	case *ast.ExprStmt:
+		return stmt.X.(*ast.TypeAssertExpr).X
 	}
-
-	for _, info := range lprog.AllPackages {
-		for _, obj := range info.Defs {
-			obj, ok := obj.(*types.TypeName)
-			if !ok || obj.IsAlias() || !visible(pkg, obj) {
-				continue
-			}
-
-			t := obj.Type().(*types.Named)
-			// Ignore iface itself and empty interfaces.
-			if i, ok := t.Underlying().(*types.Interface); ok && (iface == i || i.NumMethods() == 0) {
-				continue
-			}
-
-			if types.AssignableTo(t, iface) {
-				typs = append(typs, t)
-			} else if p := types.NewPointer(t); types.AssignableTo(p, iface) {
-				typs = append(typs, p)
-			}
-		}
-	}
-
-	sort.Sort(typesByString(typs))
-	return typs
+	return nil
 }
 
-func imported(pkg *types.Package, obj types.Object) bool {
-	return obj.Pkg() != pkg
+// typeParam returns the type parameter T if x is a conversion
+// of a value of type T to an interface type, e.g. any(v).
+func typeParam(info *types.Info, x ast.Expr) *types.TypeParam {
+	call, ok := ast.Unparen(x).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	if tv := info.Types[call.Fun]; !tv.IsType() || !types.IsInterface(tv.Type) {
+		return nil
+	}
+	tp, _ := types.Unalias(info.TypeOf(call.Args[0])).(*types.TypeParam)
+	return tp
 }
 
-func visible(pkg *types.Package, obj types.Object) bool {
-	if obj.Pkg() == pkg {
-		return true
+// searchPackages returns the packages in which to look for types implementing
+// iface, the underlying interface of typ. These are pkg itself and the packages
+// imported by f, since no other package could be referred to in a case clause.
+// Of the imported packages, the package defining a sealed interface is searched,
+// along with all packages possibly embedding one of its types. For other
+// interfaces, these are the packages of the main module importing the package
+// defining typ, or, if deps is set, all imported packages.
+func searchPackages(pkg *packages.Package, f *ast.File, typ types.Type, iface *types.Interface, deps bool) []*types.Package {
+	var typPkg *types.Package
+	if named, ok := typ.(*types.Named); ok {
+		typPkg = named.Obj().Pkg()
 	}
-	if !obj.Exported() {
-		return false
+	sealed := typPkg != nil && exhaustive.Sealed(iface)
+	var modPath string
+	if pkg.Module != nil {
+		modPath = pkg.Module.Path
 	}
 
-	// Rough approximation at the "internal" rules.
+	importers := make(map[*types.Package]bool)
+	var visit func(p *types.Package) bool
+	visit = func(p *types.Package) bool {
+		if imports, ok := importers[p]; ok {
+			return imports
+		}
+		importers[p] = false
+		imports := p == typPkg
+		for _, imp := range p.Imports() {
+			if visit(imp) {
+				imports = true
+			}
+		}
+		importers[p] = imports
+		return imports
+	}
 
-	path := obj.Pkg().Path()
-	i := 0
+	pkgs := []*types.Package{pkg.Types}
+	for _, p := range fileImports(pkg.Types, f) {
+		if p == pkg.Types {
+			continue
+		}
+		if sealed && (p == typPkg || visit(p)) || !sealed && (deps || visit(p) && inModule(modPath, p.Path())) {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
 
-	switch {
-	case strings.HasSuffix(path, "/internal"):
-		i = len(path) - len("/internal")
-	case strings.Contains(path, "/internal/"):
-		i = strings.LastIndex(path, "/internal/") + 1
-	case path == "internal", strings.HasPrefix(path, "internal/"):
-		i = 0
-	default:
-		return true
+// fileImports returns the packages imported by f, a file of pkg,
+// except for the blank imports.
+func fileImports(pkg *types.Package, f *ast.File) []*types.Package {
+	byPath := make(map[string]*types.Package)
+	for _, p := range pkg.Imports() {
+		byPath[p.Path()] = p
 	}
-	if i > 0 {
-		i--
+	var pkgs []*types.Package
+	for _, spec := range f.Imports {
+		if spec.Name != nil && spec.Name.Name == "_" {
+			continue
+		}
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if p, ok := byPath[path]; ok {
+			pkgs = append(pkgs, p)
+		}
 	}
-	prefix := path[:i]
-	return len(prefix) > 0 && strings.HasPrefix(pkg.Path(), prefix)
+	return pkgs
 }
 
-type typesByString []types.Type
-
-func (t typesByString) Len() int           { return len(t) }
-func (t typesByString) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
-func (t typesByString) Less(i, j int) bool { return t[i].String() < t[j].String() }
-
-type objsByString []types.Object
+func inModule(modPath, path string) bool {
+	return modPath != "" && (path == modPath || strings.HasPrefix(path, modPath+"/"))
+}
 
-func (o objsByString) Len() int           { return len(o) }
-func (o objsByString) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
-func (o objsByString) Less(i, j int) bool { return o[i].String() < o[j].String() }
+func imported(pkg *types.Package, obj types.Object) bool {
+	return obj.Pkg() != pkg
+}
@@ -7,23 +7,39 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"go/format"
 	"io/ioutil"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/tools/go/packages"
 )
 
 func TestFillByOffset(t *testing.T) {
 	tests := [...]struct {
 		folder string
 		offset int
+		opts   options
 	}{
 		{folder: "typeswitch_1", offset: 75},
 		{folder: "typeswitch_2", offset: 59},
-		{folder: "typeswitch_3", offset: 69},
-		{folder: "typeswitch_4", offset: 67},
-		{folder: "typeswitch_5", offset: 160},
+		{folder: "typeswitch_3", offset: 69, opts: options{deps: true}},
+		{folder: "typeswitch_4", offset: 67, opts: options{deps: true}},
+		{folder: "typeswitch_5", offset: 158},
+		{folder: "typeswitch_6", offset: 280},
 		{folder: "broken_typeswitch", offset: 146},
 		{folder: "switch_1", offset: 78},
+		{folder: "switch_2", offset: 259},
+		{folder: "switch_3", offset: 137, opts: options{vars: true}},
+		{folder: "switch_4", offset: 166},
+		{folder: "typeswitch_7", offset: 165, opts: options{body: bodyCopy}},
+		{folder: "switch_11", offset: 108, opts: options{body: bodyCopy}},
+		{folder: "switch_12", offset: 99, opts: options{body: bodyCopy}},
+		{folder: "typeswitch_8", offset: 177},
+		{folder: "typeswitch_9", offset: 303},
+		{folder: "select_1", offset: 230},
+		{folder: "switch_7", offset: 113, opts: options{reorder: true}},
+		{folder: "typeswitch_10", offset: 214, opts: options{reorder: true}},
 		{folder: "empty_switch", offset: 51},
 		{folder: "multipkgs", offset: 75},
 	}
@@ -33,13 +49,13 @@ func TestFillByOffset(t *testing.T) {
 		if err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
-		lprog, err := load(path, false)
+		pkgs, err := load(path, false, nil)
 		if err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
 
 		var buf bytes.Buffer
-		if err = byOffset(lprog, path, test.offset, &buf); err != nil {
+		if err = byOffset(pkgs, path, test.offset, test.opts, &buf); err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
 
@@ -67,14 +83,27 @@ func TestFillByLine(t *testing.T) {
 	tests := [...]struct {
 		folder string
 		line   int
+		opts   options
 	}{
 		{folder: "typeswitch_1", line: 6},
 		{folder: "typeswitch_2", line: 6},
-		{folder: "typeswitch_3", line: 9},
-		{folder: "typeswitch_4", line: 9},
+		{folder: "typeswitch_3", line: 9, opts: options{deps: true}},
+		{folder: "typeswitch_4", line: 9, opts: options{deps: true}},
 		{folder: "typeswitch_5", line: 10},
+		{folder: "typeswitch_6", line: 12},
 		{folder: "broken_typeswitch", line: 7},
 		{folder: "switch_1", line: 7},
+		{folder: "switch_2", line: 18},
+		{folder: "switch_3", line: 13, opts: options{vars: true}},
+		{folder: "switch_4", line: 19},
+		{folder: "typeswitch_7", line: 14, opts: options{body: bodyCopy}},
+		{folder: "switch_11", line: 13, opts: options{body: bodyCopy}},
+		{folder: "switch_12", line: 12, opts: options{body: bodyCopy}},
+		{folder: "typeswitch_8", line: 14},
+		{folder: "typeswitch_9", line: 19},
+		{folder: "select_1", line: 13},
+		{folder: "switch_7", line: 14, opts: options{reorder: true}},
+		{folder: "typeswitch_10", line: 18, opts: options{reorder: true}},
 		{folder: "empty_switch", line: 6},
 	}
 
@@ -83,13 +112,13 @@ func TestFillByLine(t *testing.T) {
 		if err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
-		lprog, err := load(path, false)
+		pkgs, err := load(path, false, nil)
 		if err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
 
 		var buf bytes.Buffer
-		if err = byLine(lprog, path, test.line, &buf); err != nil {
+		if err = byLine(pkgs, path, test.line, test.opts, &buf); err != nil {
 			t.Fatalf("%s: %v\n", test.folder, err)
 		}
 
@@ -112,3 +141,127 @@ func TestFillByLine(t *testing.T) {
 		}
 	}
 }
+
+func TestFillUnnamedType(t *testing.T) {
+	path, err := absPath(filepath.Join("./testdata", "basic_switch", "input.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := load(path, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = byOffset(pkgs, path, 56, options{}, &buf); err == nil {
+		t.Errorf("expected an error, got output:\n%s", buf.String())
+	}
+	if err = byLine(pkgs, path, 9, options{}, &buf); err == nil {
+		t.Errorf("expected an error, got output:\n%s", buf.String())
+	}
+}
+
+// TestErrors tests the inputs which are rejected.
+func TestErrors(t *testing.T) {
+	tests := [...]struct {
+		folder string
+		line   int
+		opts   options
+		err    string
+	}{
+		{folder: "gen_2", line: 3, opts: options{gen: "values,string"}, err: "type Level already has a method String"},
+		{folder: "switch_9", line: 11, opts: options{reorder: true}, err: "case Removed lists no existing or new values, but has statements"},
+		{folder: "switch_10", line: 11, opts: options{reorder: true}, err: "cannot reorder cases, case Info falls through"},
+		{folder: "gen_3", line: 3, opts: options{gen: "text", names: namesSnake, trimPrefix: "Method"}, err: `constants MethodGet and MethodGET have the same name "get"`},
+	}
+
+	for _, test := range tests {
+		path, err := absPath(filepath.Join("./testdata", test.folder, "input.go"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", test.folder, err)
+		}
+		pkgs, err := load(path, false, nil)
+		if err != nil {
+			t.Fatalf("%s: %v\n", test.folder, err)
+		}
+
+		var buf bytes.Buffer
+		err = byLine(pkgs, path, test.line, test.opts, &buf)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: got error %v, want %s", test.folder, err, test.err)
+		}
+	}
+}
+
+// TestEdits tests the modes which edit the file at more than one place.
+func TestEdits(t *testing.T) {
+	tests := [...]struct {
+		folder string
+		offset int
+		line   int
+		opts   options
+	}{
+		{folder: "convert_1", offset: 103, line: 12, opts: options{convert: toMap}},
+		{folder: "convert_2", offset: 205, line: 18, opts: options{convert: toSwitch}},
+		{folder: "convert_3", offset: 205, line: 18, opts: options{convert: toSwitch}},
+		{folder: "gen_1", offset: 52, line: 4, opts: options{gen: "string,text,values", names: namesSnake, trimPrefix: "Proto"}},
+		{folder: "gen_4", offset: 20, line: 3, opts: options{gen: "string"}},
+		{folder: "gen_5", offset: 36, line: 5, opts: options{gen: "string"}},
+		{folder: "switch_5", offset: 89, line: 12, opts: options{body: bodyPanic, withDefault: true}},
+		{folder: "switch_6", offset: 141, line: 15, opts: options{body: bodyError}},
+		{folder: "switch_13", offset: 113, line: 13, opts: options{body: bodyError}},
+		{folder: "switch_8", offset: 91, line: 12, opts: options{body: bodyTODO}},
+	}
+
+	for _, test := range tests {
+		path, err := absPath(filepath.Join("./testdata", test.folder, "input.go"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", test.folder, err)
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v\n", test.folder, err)
+		}
+		want, err := ioutil.ReadFile(filepath.Join("./testdata", test.folder, "output.golden"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", test.folder, err)
+		}
+
+		opts := test.opts
+		for _, by := range []func([]*packages.Package, *bytes.Buffer) error{
+			func(pkgs []*packages.Package, buf *bytes.Buffer) error {
+				return byOffset(pkgs, path, test.offset, opts, buf)
+			},
+			func(pkgs []*packages.Package, buf *bytes.Buffer) error {
+				return byLine(pkgs, path, test.line, opts, buf)
+			},
+		} {
+			// Filling modifies the syntax trees of the packages.
+			pkgs, err := load(path, false, nil)
+			if err != nil {
+				t.Fatalf("%s: %v\n", test.folder, err)
+			}
+			var buf bytes.Buffer
+			if err = by(pkgs, &buf); err != nil {
+				t.Fatalf("%s: %v\n", test.folder, err)
+			}
+
+			var outs []output
+			if err = json.NewDecoder(&buf).Decode(&outs); err != nil {
+				t.Fatalf("%s: %v\n", test.folder, err)
+			}
+			got := append([]byte(nil), src...)
+			for _, out := range outs {
+				got = append(got[:out.Start:out.Start], append([]byte(out.Code), got[out.End:]...)...)
+			}
+			// Editors reformat the file after applying the edits.
+			if got, err = format.Source(got); err != nil {
+				t.Fatalf("%s: %v\n", test.folder, err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s:\ngot:\n%s\n\nwant:\n%s\n\n", test.folder, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/shiba6v/reftools/cmd/fillswitch/exhaustive"
+	"golang.org/x/tools/go/packages"
+)
+
+// Methods and functions generated for enum types.
+const (
+	genString = "string" // String() string
+	genText   = "text"   // MarshalText and UnmarshalText
+	genValues = "values" // <Type>Values() []<Type>
+)
+
+// Transformations of constant names into the names used by the generated code.
+const (
+	namesAsIs  = ""      // KindFooBar
+	namesSnake = "snake" // kind_foo_bar
+	namesKebab = "kebab" // kind-foo-bar
+)
+
+func validGen(gen string) bool {
+	if gen == "" {
+		return true
+	}
+	for _, g := range strings.Split(gen, ",") {
+		if g != genString && g != genText && g != genValues {
+			return false
+		}
+	}
+	return true
+}
+
+func validNames(names string) bool {
+	return names == namesAsIs || names == namesSnake || names == namesKebab
+}
+
+// generate generates the code requested by opts.gen for the innermost
+// type for which contains returns true and writes the resulting edits.
+func generate(pkg *packages.Package, f *ast.File, contains func(ast.Node) bool, opts options, dst io.Writer) error {
+	var named *types.Named
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || !contains(n) {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok {
+			if obj, ok := pkg.TypesInfo.ObjectOf(id).(*types.TypeName); ok {
+				if t, ok := obj.Type().(*types.Named); ok && named == nil {
+					named = t
+				}
+			}
+		}
+		return true
+	})
+	if named == nil {
+		return errors.New("no type found")
+	}
+	if named.Obj().Pkg() != pkg.Types {
+		return fmt.Errorf("cannot generate methods for type %s declared in another package", typeString(pkg.Types, named))
+	}
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok {
+		return fmt.Errorf("type %s is not an enum", named.Obj().Name())
+	}
+	var consts []*types.Const
+	for _, obj := range exhaustive.Consts(pkg.Fset, pkg.Syntax, pkg.Types, named, false) {
+		consts = append(consts, obj.(*types.Const))
+	}
+	if len(consts) == 0 {
+		return fmt.Errorf("type %s has no constants", named.Obj().Name())
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	gens := strings.Split(opts.gen, ",")
+	if err := checkDeclared(pkg, named, gens); err != nil {
+		return err
+	}
+
+	g := generator{
+		typ:   named.Obj().Name(),
+		recv:  receiverName(pkg.TypesInfo.Scopes[f], named.Obj().Name()),
+		basic: basic.Name(),
+	}
+	byName := make(map[string]string) // transformed name -> constant
+	for _, c := range consts {
+		name := transformName(c.Name(), opts.trimPrefix, opts.names)
+		if other, ok := byName[name]; ok && containsString(gens, genText) {
+			return fmt.Errorf("constants %s and %s have the same name %q", other, c.Name(), name)
+		}
+		byName[name] = c.Name()
+		g.consts = append(g.consts, c.Name())
+		g.names = append(g.names, name)
+	}
+
+	var (
+		code    bytes.Buffer
+		imports []string
+	)
+	for _, gen := range gens {
+		switch gen {
+		case genString:
+			g.writeString(&code)
+		case genText:
+			g.writeText(&code)
+		case genValues:
+			g.writeValues(&code)
+		}
+		if gen == genString || gen == genText {
+			imports = append(imports, "fmt")
+		}
+	}
+	src, err := format.Source(code.Bytes())
+	if err != nil {
+		return err
+	}
+
+	pos := insertPos(pkg, f, named.Obj(), consts)
+	outs := []output{{Start: pos, End: pos, Code: "\n\n" + string(src)}}
+	if imp := importEdit(pkg.Fset, f, imports); imp != nil {
+		outs = append(outs, *imp)
+	}
+	return writeEdits(outs, dst)
+}
+
+// checkDeclared returns an error if one of the methods or functions
+// generated for named by gens is already declared.
+func checkDeclared(pkg *packages.Package, named *types.Named, gens []string) error {
+	var methods []string
+	for _, gen := range gens {
+		switch gen {
+		case genString:
+			methods = append(methods, "String")
+		case genText:
+			methods = append(methods, "MarshalText", "UnmarshalText")
+		case genValues:
+			if name := named.Obj().Name() + "Values"; pkg.Types.Scope().Lookup(name) != nil {
+				return fmt.Errorf("%s is already declared", name)
+			}
+		}
+	}
+	for _, name := range methods {
+		if obj, _, _ := types.LookupFieldOrMethod(named, true, pkg.Types, name); obj != nil {
+			return fmt.Errorf("type %s already has a method %s", named.Obj().Name(), name)
+		}
+	}
+	return nil
+}
+
+// receiverName returns the name of the receiver of the methods of the
+// type typ, the lower-cased initial of typ unless that would shadow an
+// object in the file scope scope or the fmt package used by the generated code.
+func receiverName(scope *types.Scope, typ string) string {
+	taken := func(name string) bool {
+		if name == "fmt" {
+			return true
+		}
+		_, obj := scope.LookupParent(name, token.NoPos)
+		return obj != nil
+	}
+	initial := string(unicode.ToLower([]rune(typ)[0]))
+	for _, name := range []string{initial, strings.ToLower(typ)} {
+		if !taken(name) {
+			return name
+		}
+	}
+	for i := 1; ; i++ {
+		if name := initial + strconv.Itoa(i); !taken(name) {
+			return name
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generator writes the code for the enum type typ.
+type generator struct {
+	typ    string   // name of the type
+	recv   string   // receiver name
+	basic  string   // name of the underlying type
+	consts []string // constants in declaration order
+	names  []string // transformed names of the constants
+}
+
+func (g *generator) writeString(w io.Writer) {
+	fmt.Fprintf(w, "// String returns the name of %s.\n", g.recv)
+	fmt.Fprintf(w, "func (%s %s) String() string {\n", g.recv, g.typ)
+	fmt.Fprintf(w, "switch %s {\n", g.recv)
+	for i, c := range g.consts {
+		fmt.Fprintf(w, "case %s:\nreturn %s\n", c, strconv.Quote(g.names[i]))
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "return fmt.Sprintf(\"%s(%%v)\", %s(%s))\n}\n", g.typ, g.basic, g.recv)
+}
+
+func (g *generator) writeText(w io.Writer) {
+	fmt.Fprintf(w, "\n// MarshalText implements encoding.TextMarshaler.\n")
+	fmt.Fprintf(w, "func (%s %s) MarshalText() ([]byte, error) {\n", g.recv, g.typ)
+	fmt.Fprintf(w, "switch %s {\n", g.recv)
+	for i, c := range g.consts {
+		fmt.Fprintf(w, "case %s:\nreturn []byte(%s), nil\n", c, strconv.Quote(g.names[i]))
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "return nil, fmt.Errorf(\"invalid %s %%v\", %s(%s))\n}\n", g.typ, g.basic, g.recv)
+
+	fmt.Fprintf(w, "\n// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(w, "func (%s *%s) UnmarshalText(text []byte) error {\n", g.recv, g.typ)
+	fmt.Fprintf(w, "switch string(text) {\n")
+	for i, c := range g.consts {
+		fmt.Fprintf(w, "case %s:\n*%s = %s\n", strconv.Quote(g.names[i]), g.recv, c)
+	}
+	fmt.Fprintf(w, "default:\nreturn fmt.Errorf(\"invalid %s %%q\", text)\n}\n", g.typ)
+	fmt.Fprintf(w, "return nil\n}\n")
+}
+
+func (g *generator) writeValues(w io.Writer) {
+	fmt.Fprintf(w, "\n// %sValues returns all values of %s.\n", g.typ, g.typ)
+	fmt.Fprintf(w, "func %sValues() []%s {\n", g.typ, g.typ)
+	fmt.Fprintf(w, "return []%s{\n", g.typ)
+	for _, c := range g.consts {
+		fmt.Fprintf(w, "%s,\n", c)
+	}
+	fmt.Fprintf(w, "}\n}\n")
+}
+
+// transformName trims prefix from name and converts
+// the rest according to names, e.g. to snake case.
+func transformName(name, prefix, names string) string {
+	if trimmed := strings.TrimPrefix(name, prefix); trimmed != "" {
+		name = trimmed
+	}
+	switch names {
+	case namesSnake:
+		return joinWords(name, '_')
+	case namesKebab:
+		return joinWords(name, '-')
+	}
+	return name
+}
+
+// joinWords splits a camel case or snake case name into lower case words
+// and joins them with sep. Acronyms are kept together, e.g. HTTPServer
+// becomes http_server.
+func joinWords(name string, sep rune) string {
+	runes := []rune(name)
+	var buf strings.Builder
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			if buf.Len() > 0 {
+				buf.WriteRune(sep)
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && buf.Len() > 0 {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				buf.WriteRune(sep)
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// insertPos returns the offset after the last declaration
+// of f declaring typ or one of consts, or the end of f.
+func insertPos(pkg *packages.Package, f *ast.File, typ *types.TypeName, consts []*types.Const) int {
+	declared := map[types.Object]bool{typ: true}
+	for _, c := range consts {
+		declared[c] = true
+	}
+
+	var end token.Pos
+	for _, d := range f.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			var names []*ast.Ident
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = []*ast.Ident{spec.Name}
+			case *ast.ValueSpec:
+				names = spec.Names
+			}
+			for _, name := range names {
+				if declared[pkg.TypesInfo.Defs[name]] && gen.End() > end {
+					end = gen.End()
+				}
+			}
+		}
+	}
+	if !end.IsValid() {
+		end = f.End()
+	}
+	return pkg.Fset.Position(end).Offset
+}
+
+// importEdit returns an edit adding import declarations for the paths
+// not yet imported by f, or nil if there are none. The added code refers
+// to the packages by the last elements of their paths, so a package
+// imported with another name, e.g. the blank identifier, is imported again.
+func importEdit(fset *token.FileSet, f *ast.File, paths []string) *output {
+	imported := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name == nil || imp.Name.Name == path[strings.LastIndex(path, "/")+1:] {
+			imported[path] = true
+		}
+	}
+	var code bytes.Buffer
+	for _, path := range paths {
+		if !imported[path] {
+			imported[path] = true
+			fmt.Fprintf(&code, "\n\nimport %s", strconv.Quote(path))
+		}
+	}
+	if code.Len() == 0 {
+		return nil
+	}
+	pos := fset.Position(f.Name.End()).Offset
+	return &output{Start: pos, End: pos, Code: code.String()}
+}
@@ -2,7 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Fillswitch fills a (type) switch with case statements.
+// Fillswitch fills a (type) switch with case statements
+// and a select statement with communication clauses.
 //
 // For example, the following (type) switches,
 //
@@ -54,9 +55,30 @@
 //
 // after applying fillswitch for the (type) switch statements.
 //
+// Switch statements are filled with the constants declared with the
+// type of the tag expression in the package defining the type. Switches
+// over values of unnamed types, like int or string, are not filled.
+//
+// Type switches over values of sealed interfaces, i.e. interfaces with an
+// unexported method, are filled with the types of the package defining the
+// interface and the types embedding one of them. For other interfaces, the
+// implementing types are taken from the main module, unless the -deps flag
+// is given. Only the package of the switch and the packages imported by its
+// file are searched, as no other types can be referred to in a case clause.
+//
+// A type switch over a value of a type parameter converted to an interface,
+// like any(v), is filled with the types of the union terms of the constraint.
+// Instantiated generic types, like *Box[int], are added if they are used in
+// the package of the switch statement.
+//
+// A select statement is filled with a receive from each channel variable in
+// scope, or a send of the zero value for send-only channels, and a receive
+// from ctx.Done() for each context.Context ctx in scope. The -body and
+// -default flags do not apply to select statements.
+//
 // Usage:
 //
-// 	% fillstruct [-modified] -file=<filename> -offset=<byte offset> -line=<line number>
+// 	% fillswitch [-modified] [-tags=<build tags>] [-vars] [-deps] [-body=<template>] [-default] [-reorder] [-convert=map|switch] [-gen=<list>] [-names=snake|kebab] [-trimprefix=<prefix>] -file=<filename> -offset=<byte offset> -line=<line number>
 //
 // Flags:
 //
@@ -64,9 +86,52 @@
 //
 // -modified: read an archive of modified files from stdin
 //
-// -offset:   byte offset of the (type) switch, optional if -line is present
+// -tags:     a list of build tags to consider satisfied during the build
+//
+// -vars:     also add cases for package-level variables of the switched type
+//
+// -deps:     also add cases for types outside of the main module implementing
+//            the switched interface, unless the interface is sealed
+//
+// -body:     template for the bodies of the added cases, one of
+//            panic: panic("unhandled X")
+//            error: return fmt.Errorf("unexpected %v", x), along with zero values
+//                   for the other results of the enclosing function
+//            todo:  a TODO comment at the end of the case line
+//            copy:  a copy of the body of the last existing case without a
+//                   trailing fallthrough, or panic if nothing else is left
+//
+// -default:  add a default clause panicking with the unhandled value,
+//            unless the switch already has one
+//            The fmt package used by the error template and the default clause
+//            is imported if necessary.
+//
+// -reorder:  sort all cases in declaration order of their constants or types,
+//            remove cases of constants or types which do not exist anymore and
+//            remove duplicate case expressions; a case clause with statements
+//            is never removed, fillswitch fails instead, as it does for a switch
+//            with a clause ending in fallthrough
+//
+// -convert:  instead of filling, convert between switch statements and map lookups
+//            map:    convert a switch whose cases each return a single constant into
+//                    a lookup of a package-level map declared after the function
+//            switch: convert a lookup of a package-level map, return m[k] or
+//                    if v, ok := m[k]; ok { return v }, into a switch statement
+//            Constants of the key type missing in the result are listed in a comment.
+//
+// -gen:      instead of filling, generate code for the enum type at the offset or line:
+//            comma-separated list of
+//            string: a String method
+//            text:   MarshalText and UnmarshalText methods
+//            values: a function <Type>Values returning all constants of the type
+//
+// -names:    transformation of the constant names in generated code: snake or kebab
+//
+// -trimprefix: prefix to trim from the constant names in generated code
+//
+// -offset:   byte offset of the (type) switch or select, optional if -line is present
 //
-// -line:     line number of the (type) switch, optional if -offset is present
+// -line:     line number of the (type) switch or select, optional if -offset is present
 //
 // If -offset as well as -line are present, then the tool first uses the
 // more specific offset information. If there was no (type) switch found
@@ -81,23 +146,21 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/format"
-	"go/parser"
 	"go/token"
 	"go/types"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/buildutil"
-	"golang.org/x/tools/go/loader"
-	"golang.org/x/tools/refactor/importgraph"
+	"golang.org/x/tools/go/packages"
 )
 
-var errNotFound = errors.New("no switch statement found")
+var errNotFound = errors.New("no switch or select statement found")
 
 func main() {
 	log.SetFlags(0)
@@ -106,12 +169,23 @@ func main() {
 	var (
 		filename = flag.String("file", "", "filename")
 		modified = flag.Bool("modified", false, "read an archive of modified files from stdin")
-		offset   = flag.Int("offset", 0, "byte offset of the (type) switch, optional if -line is present")
-		line     = flag.Int("line", 0, "line number of the (type) switch, optional if -offset is present")
+		offset   = flag.Int("offset", 0, "byte offset of the (type) switch or select, optional if -line is present")
+		line     = flag.Int("line", 0, "line number of the (type) switch or select, optional if -offset is present")
+		vars     = flag.Bool("vars", false, "also add cases for package-level variables of the switched type")
+		body     = flag.String("body", "", "template for the bodies of the added cases: panic, error, todo or copy")
+		dflt     = flag.Bool("default", false, "add a default clause panicking with the unhandled value")
+		conv     = flag.String("convert", "", "convert a switch statement into a map lookup (map) or vice versa (switch)")
+		gen      = flag.String("gen", "", "generate code for the enum type instead: comma-separated list of string, text and values")
+		names    = flag.String("names", "", "transformation of constant names in generated code: snake or kebab")
+		trim     = flag.String("trimprefix", "", "prefix to trim from constant names in generated code")
+		reorder  = flag.Bool("reorder", false, "sort all cases by declaration and remove stale and duplicate cases")
+		deps     = flag.Bool("deps", false, "also add cases for types outside of the main module implementing the switched interface")
+		btags    buildutil.TagsFlag
 	)
+	flag.Var(&btags, "tags", buildutil.TagsFlagDoc)
 	flag.Parse()
 
-	if (*offset == 0 && *line == 0) || *filename == "" {
+	if (*offset == 0 && *line == 0) || *filename == "" || !validBody(*body) || !validConvert(*conv) || !validGen(*gen) || !validNames(*names) {
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -121,13 +195,17 @@ func main() {
 		log.Fatal(err)
 	}
 
-	lprog, err := load(path, *modified)
+	pkgs, err := load(path, *modified, btags)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	opts := options{vars: *vars, deps: *deps, body: *body, withDefault: *dflt, convert: *conv,
+		gen: *gen, names: *names, trimPrefix: *trim,
+		reorder: *reorder}
+
 	if *offset > 0 {
-		err = byOffset(lprog, path, *offset, os.Stdout)
+		err = byOffset(pkgs, path, *offset, opts, os.Stdout)
 		switch err {
 		case nil:
 			return
@@ -139,7 +217,7 @@ func main() {
 	}
 
 	if *line > 0 {
-		err = byLine(lprog, path, *line, os.Stdout)
+		err = byLine(pkgs, path, *line, opts, os.Stdout)
 		switch err {
 		case nil:
 			return
@@ -159,70 +237,79 @@ func absPath(filename string) (string, error) {
 	return filepath.Abs(eval)
 }
 
-func load(path string, modified bool) (*loader.Program, error) {
-	ctx := &build.Default
+// load loads the package containing path, including its tests.
+// Dependencies are type-checked from export data; the candidates
+// for the case clauses are later taken from the package scopes.
+func load(path string, modified bool, tags []string) ([]*packages.Package, error) {
+	var overlay map[string][]byte
 	if modified {
-		archive, err := buildutil.ParseOverlayArchive(os.Stdin)
+		var err error
+		overlay, err = buildutil.ParseOverlayArchive(os.Stdin)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid archive: %v", err)
 		}
-		ctx = buildutil.OverlayContext(ctx, archive)
 	}
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, err
+
+	cfg := &packages.Config{
+		Overlay:    overlay,
+		Mode:       packages.LoadSyntax | packages.NeedModule,
+		Tests:      true,
+		Dir:        filepath.Dir(path),
+		Fset:       token.NewFileSet(),
+		BuildFlags: []string{"-tags", strings.Join(tags, ",")},
+		Env:        os.Environ(),
 	}
-	pkg, err := buildutil.ContainingPackage(ctx, cwd, path)
+	pkgs, err := packages.Load(cfg, "file="+path)
 	if err != nil {
 		return nil, err
 	}
-
-	conf := &loader.Config{Build: ctx}
-	allowErrors(conf)
-	conf.ImportWithTests(pkg.ImportPath)
-
-	_, rev, _ := importgraph.Build(ctx)
-	for p := range rev.Search(pkg.ImportPath) {
-		conf.ImportWithTests(p)
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("can't find package containing %s", path)
 	}
-	return conf.Load()
-}
-
-func allowErrors(lconf *loader.Config) {
-	ctxt := *lconf.Build
-	ctxt.CgoEnabled = false
-	lconf.Build = &ctxt
-	lconf.AllowErrors = true
-	lconf.ParserMode = parser.AllErrors
-	lconf.TypeChecker.Error = func(error) {}
+	return pkgs, nil
 }
 
-func byOffset(lprog *loader.Program, path string, offset int, dst io.Writer) error {
-	f, pkg, pos, err := findPos(lprog, path, offset)
+func byOffset(pkgs []*packages.Package, path string, offset int, opts options, dst io.Writer) error {
+	f, pkg, pos, err := findPos(pkgs, path, offset)
 	if err != nil {
 		return err
 	}
+	if opts.convert != "" || opts.gen != "" {
+		contains := func(n ast.Node) bool { return n.Pos() <= pos && pos <= n.End() }
+		if opts.gen != "" {
+			return generate(pkg, f, contains, opts, dst)
+		}
+		return convert(pkg, f, contains, opts.convert, dst)
+	}
 
-	swtch, typ, err := findSwitchStmt(f, pkg.Info, pos)
+	swtch, typ, err := findSwitchStmt(f, pkg.TypesInfo, pos)
 	if err != nil {
 		return err
 	}
 
-	start := lprog.Fset.Position(swtch.Pos()).Offset
-	end := lprog.Fset.Position(swtch.End()).Offset
+	start := pkg.Fset.Position(swtch.Pos()).Offset
+	end := pkg.Fset.Position(swtch.End()).Offset
 
-	newSwtch := fillSwitch(pkg, lprog, swtch, typ)
-	out, err := prepareOutput(newSwtch, start, end)
+	add := newAdditions()
+	newSwtch, err := fillSwitch(pkg, f, swtch, typ, opts, add)
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(dst).Encode([]output{out})
+	out, err := prepareOutput(newSwtch, add, start, end)
+	if err != nil {
+		return err
+	}
+	outs := []output{out}
+	if imp := importEdit(pkg.Fset, f, add.imports); imp != nil {
+		outs = append(outs, *imp)
+	}
+	return json.NewEncoder(dst).Encode(outs)
 }
 
-func findPos(lprog *loader.Program, path string, offset int) (*ast.File, *loader.PackageInfo, token.Pos, error) {
-	for _, pkg := range lprog.InitialPackages() {
-		for _, f := range pkg.Files {
-			if file := lprog.Fset.File(f.Pos()); file.Name() == path {
+func findPos(pkgs []*packages.Package, path string, offset int) (*ast.File, *packages.Package, token.Pos, error) {
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			if file := pkg.Fset.File(f.Pos()); file.Name() == path {
 				if offset > file.Size() {
 					return nil, nil, 0,
 						fmt.Errorf("file size (%d) is smaller than given offset (%d)", file.Size(), offset)
@@ -234,7 +321,7 @@ func findPos(lprog *loader.Program, path string, offset int) (*ast.File, *loader
 	return nil, nil, 0, fmt.Errorf("could not find file %q", path)
 }
 
-func findSwitchStmt(f *ast.File, info types.Info, pos token.Pos) (ast.Stmt, types.Type, error) {
+func findSwitchStmt(f *ast.File, info *types.Info, pos token.Pos) (ast.Stmt, types.Type, error) {
 	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
 	for _, n := range path {
 		switch n := n.(type) {
@@ -250,6 +337,9 @@ func findSwitchStmt(f *ast.File, info types.Info, pos token.Pos) (ast.Stmt, type
 			}
 			return nil, nil, errors.New("invalid type switch")
 
+		case *ast.SelectStmt:
+			return n, nil, nil
+
 		default:
 			// continue
 		}
@@ -257,12 +347,12 @@ func findSwitchStmt(f *ast.File, info types.Info, pos token.Pos) (ast.Stmt, type
 	return nil, nil, errNotFound
 }
 
-func byLine(lprog *loader.Program, path string, line int, dst io.Writer) (err error) {
+func byLine(pkgs []*packages.Package, path string, line int, opts options, dst io.Writer) (err error) {
 	var f *ast.File
-	var pkg *loader.PackageInfo
-	for _, p := range lprog.InitialPackages() {
-		for _, af := range p.Files {
-			if file := lprog.Fset.File(af.Pos()); file.Name() == path {
+	var pkg *packages.Package
+	for _, p := range pkgs {
+		for _, af := range p.Syntax {
+			if file := p.Fset.File(af.Pos()); file.Name() == path {
 				f = af
 				pkg = p
 			}
@@ -271,31 +361,45 @@ func byLine(lprog *loader.Program, path string, line int, dst io.Writer) (err er
 	if f == nil || pkg == nil {
 		return fmt.Errorf("could not find file %q", path)
 	}
+	if opts.convert != "" || opts.gen != "" {
+		contains := func(n ast.Node) bool {
+			return pkg.Fset.Position(n.Pos()).Line <= line && line <= pkg.Fset.Position(n.End()).Line
+		}
+		if opts.gen != "" {
+			return generate(pkg, f, contains, opts, dst)
+		}
+		return convert(pkg, f, contains, opts.convert, dst)
+	}
 
 	var outs []output
+	add := newAdditions()
 	ast.Inspect(f, func(n ast.Node) bool {
 		switch swtch := n.(type) {
 		case *ast.SwitchStmt:
-			startLine := lprog.Fset.Position(swtch.Pos()).Line
-			endLine := lprog.Fset.Position(swtch.End()).Line
+			startLine := pkg.Fset.Position(swtch.Pos()).Line
+			endLine := pkg.Fset.Position(swtch.End()).Line
 			if !(startLine <= line && line <= endLine) {
 				return true
 			}
 
-			start := lprog.Fset.Position(swtch.Pos()).Offset
-			end := lprog.Fset.Position(swtch.End()).Offset
-			newSwtch := fillSwitch(pkg, lprog, swtch, pkg.Info.Types[swtch.Tag].Type)
+			start := pkg.Fset.Position(swtch.Pos()).Offset
+			end := pkg.Fset.Position(swtch.End()).Offset
+			var newSwtch ast.Stmt
+			newSwtch, err = fillSwitch(pkg, f, swtch, pkg.TypesInfo.Types[swtch.Tag].Type, opts, add)
+			if err != nil {
+				return false
+			}
 
 			var out output
-			out, err = prepareOutput(newSwtch, start, end)
+			out, err = prepareOutput(newSwtch, add, start, end)
 			if err != nil {
 				return false
 			}
 			outs = append(outs, out)
 
 		case *ast.TypeSwitchStmt:
-			startLine := lprog.Fset.Position(swtch.Pos()).Line
-			endLine := lprog.Fset.Position(swtch.End()).Line
+			startLine := pkg.Fset.Position(swtch.Pos()).Line
+			endLine := pkg.Fset.Position(swtch.End()).Line
 			if !(startLine <= line && line <= endLine) {
 				return true
 			}
@@ -303,19 +407,45 @@ func byLine(lprog *loader.Program, path string, line int, dst io.Writer) (err er
 			var typ types.Type
 			switch stmt := swtch.Assign.(type) {
 			case *ast.AssignStmt:
-				typ = pkg.Info.Types[stmt.Rhs[0].(*ast.TypeAssertExpr).X].Type
+				typ = pkg.TypesInfo.Types[stmt.Rhs[0].(*ast.TypeAssertExpr).X].Type
 			case *ast.ExprStmt:
-				typ = pkg.Info.Types[stmt.X.(*ast.TypeAssertExpr).X].Type
+				typ = pkg.TypesInfo.Types[stmt.X.(*ast.TypeAssertExpr).X].Type
 			default:
 				return true
 			}
 
-			newSwtch := fillSwitch(pkg, lprog, swtch, typ)
-			start := lprog.Fset.Position(swtch.Pos()).Offset
-			end := lprog.Fset.Position(swtch.End()).Offset
+			var newSwtch ast.Stmt
+			newSwtch, err = fillSwitch(pkg, f, swtch, typ, opts, add)
+			if err != nil {
+				return false
+			}
+			start := pkg.Fset.Position(swtch.Pos()).Offset
+			end := pkg.Fset.Position(swtch.End()).Offset
+
+			var out output
+			out, err = prepareOutput(newSwtch, add, start, end)
+			if err != nil {
+				return false
+			}
+			outs = append(outs, out)
+
+		case *ast.SelectStmt:
+			startLine := pkg.Fset.Position(swtch.Pos()).Line
+			endLine := pkg.Fset.Position(swtch.End()).Line
+			if !(startLine <= line && line <= endLine) {
+				return true
+			}
+
+			start := pkg.Fset.Position(swtch.Pos()).Offset
+			end := pkg.Fset.Position(swtch.End()).Offset
+			var newSel ast.Stmt
+			newSel, err = fillSwitch(pkg, f, swtch, nil, opts, add)
+			if err != nil {
+				return false
+			}
 
 			var out output
-			out, err = prepareOutput(newSwtch, start, end)
+			out, err = prepareOutput(newSel, add, start, end)
 			if err != nil {
 				return false
 			}
@@ -338,6 +468,9 @@ func byLine(lprog *loader.Program, path string, line int, dst io.Writer) (err er
 		opp := len(outs) - 1 - i
 		outs[i], outs[opp] = outs[opp], outs[i]
 	}
+	if imp := importEdit(pkg.Fset, f, add.imports); imp != nil {
+		outs = append(outs, *imp)
+	}
 
 	return json.NewEncoder(dst).Encode(outs)
 }
@@ -348,14 +481,21 @@ type output struct {
 	Code  string `json:"code"`
 }
 
-func prepareOutput(n ast.Node, start, end int) (output, error) {
+func prepareOutput(n ast.Node, add *additions, start, end int) (output, error) {
 	var buf bytes.Buffer
 	if err := format.Node(&buf, token.NewFileSet(), n); err != nil {
 		return output{}, err
 	}
+	code := buf.String()
+	if len(add.comments) > 0 {
+		var err error
+		if code, err = addComments(n, code, add.comments); err != nil {
+			return output{}, err
+		}
+	}
 	return output{
 		Start: start,
 		End:   end,
-		Code:  buf.String(),
+		Code:  code,
 	}, nil
 }
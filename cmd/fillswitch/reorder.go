@@ -0,0 +1,172 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// caseKey determines the position of a case expression in the reordered
+// switch statement and identifies duplicate case expressions.
+type caseKey struct {
+	valid bool      // whether the expression refers to an existing constant or type
+	group int       // expressions are ordered by group first
+	path  string    // then by the path of the declaring package
+	pos   token.Pos // and then by the position of the declaration
+	id    string    // expressions with the same id are duplicates
+}
+
+func (k caseKey) less(l caseKey) bool {
+	if k.group != l.group {
+		return k.group < l.group
+	}
+	if k.path != l.path {
+		return k.path < l.path
+	}
+	return k.pos < l.pos
+}
+
+// reorderCases sorts the case clauses of body by the declarations of the
+// constants or types they list, removes case expressions referring to
+// constants or types which do not exist (anymore) and duplicate case
+// expressions. Empty case clauses left without expressions are removed and
+// the default clause is moved to the end. An error is returned instead of
+// removing a case clause with statements, and if a clause falls through,
+// since moving it would change the clause it falls through to.
+func reorderCases(body *ast.BlockStmt, key func(ast.Expr) caseKey) error {
+	type clause struct {
+		cc  *ast.CaseClause
+		key caseKey
+	}
+	var (
+		clauses []clause
+		dflt    *ast.CaseClause
+	)
+	for _, stmt := range body.List {
+		if cc := stmt.(*ast.CaseClause); fallsThrough(cc) {
+			if cc.List == nil {
+				return errors.New("cannot reorder cases, default falls through")
+			}
+			return fmt.Errorf("cannot reorder cases, case %s falls through", exprsString(cc.List))
+		}
+	}
+	seen := make(map[string]bool)
+	for _, stmt := range body.List {
+		cc := stmt.(*ast.CaseClause)
+		if cc.List == nil {
+			dflt = cc
+			continue
+		}
+
+		var (
+			list []ast.Expr
+			min  caseKey
+		)
+		for _, e := range cc.List {
+			k := key(e)
+			if !k.valid || seen[k.id] {
+				continue
+			}
+			seen[k.id] = true
+			if list == nil || k.less(min) {
+				min = k
+			}
+			list = append(list, e)
+		}
+		if list == nil {
+			if len(cc.Body) > 0 {
+				return fmt.Errorf("case %s lists no existing or new values, but has statements", exprsString(cc.List))
+			}
+			continue
+		}
+		sort.SliceStable(list, func(i, j int) bool { return key(list[i]).less(key(list[j])) })
+		cc.List = list
+		clauses = append(clauses, clause{cc: cc, key: min})
+	}
+
+	sort.SliceStable(clauses, func(i, j int) bool { return clauses[i].key.less(clauses[j].key) })
+	body.List = body.List[:0]
+	for _, c := range clauses {
+		body.List = append(body.List, c.cc)
+	}
+	if dflt != nil {
+		body.List = append(body.List, dflt)
+	}
+	return nil
+}
+
+// fallsThrough reports whether the case clause cc ends with a fallthrough statement.
+func fallsThrough(cc *ast.CaseClause) bool {
+	if len(cc.Body) == 0 {
+		return false
+	}
+	br, ok := cc.Body[len(cc.Body)-1].(*ast.BranchStmt)
+	return ok && br.Tok == token.FALLTHROUGH
+}
+
+// objKey returns the key of a case expression referring to the package-level
+// object obj, which is identified by id. Objects are ordered by declaration.
+func objKey(obj types.Object, id string) caseKey {
+	return caseKey{valid: true, path: obj.Pkg().Path(), pos: obj.Pos(), id: id}
+}
+
+// typeKey returns the key of a case listing the type t. nil and predeclared
+// types come first, followed by named types and pointers to them ordered by
+// package and declaration, and other types come last.
+func typeKey(pkg *types.Package, t types.Type) caseKey {
+	k := caseKey{valid: true, group: 2, id: typeString(pkg, t)}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		k.group = 0 // nil
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			k.group = 0 // error
+		} else {
+			k.group, k.path, k.pos = 1, t.Obj().Pkg().Path(), t.Obj().Pos()
+		}
+	}
+	return k
+}
+
+// exprKey returns the key of the existing case expression e.
+// Expressions which are not constants come last.
+func exprKey(pkg *packages.Package, e ast.Expr, typeSwitch bool) caseKey {
+	tv, ok := pkg.TypesInfo.Types[e]
+	if !ok || tv.Type == nil || tv.Type == types.Typ[types.Invalid] {
+		return caseKey{}
+	}
+	if typeSwitch {
+		return typeKey(pkg.Types, tv.Type)
+	}
+	id := types.ExprString(e)
+	if tv.Value != nil {
+		id = tv.Value.ExactString()
+	}
+	if obj := pkg.TypesInfo.Uses[caseIdent(e)]; obj != nil && obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+		return objKey(obj, id)
+	}
+	return caseKey{valid: true, group: 1, id: id}
+}
+
+// caseIdent returns the identifier of the possibly qualified name e.
+func caseIdent(e ast.Expr) *ast.Ident {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// fillSelect adds a communication clause to sel for each channel variable
+// in scope at sel: a send of the zero value for send-only channels and a
+// receive otherwise. If a context.Context is in scope, a receive from its
+// Done channel is added as well. Channels already used are skipped.
+func fillSelect(pkg *packages.Package, sel *ast.SelectStmt) ast.Stmt {
+	existing := make(map[string]bool)
+	for _, stmt := range sel.Body.List {
+		if ch := commChan(stmt.(*ast.CommClause).Comm); ch != nil {
+			existing[types.ExprString(ch)] = true
+		}
+	}
+
+	var chans, ctxs []*types.Var
+	for _, v := range varsInScope(pkg.Types, sel.Pos()) {
+		switch {
+		case isContext(v.Type()):
+			ctxs = append(ctxs, v)
+		case isChan(v.Type()):
+			chans = append(chans, v)
+		}
+	}
+
+	for _, v := range chans {
+		if existing[v.Name()] {
+			continue
+		}
+		ch := v.Type().Underlying().(*types.Chan)
+		var comm ast.Stmt
+		if ch.Dir() == types.SendOnly {
+			comm = &ast.SendStmt{Chan: ast.NewIdent(v.Name()), Value: zeroValue(pkg.Types, ch.Elem())}
+		} else {
+			comm = &ast.ExprStmt{X: &ast.UnaryExpr{Op: token.ARROW, X: ast.NewIdent(v.Name())}}
+		}
+		sel.Body.List = append(sel.Body.List, &ast.CommClause{Comm: comm})
+	}
+	for _, v := range ctxs {
+		done := &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(v.Name()), Sel: ast.NewIdent("Done")}}
+		if existing[types.ExprString(done)] {
+			continue
+		}
+		sel.Body.List = append(sel.Body.List, &ast.CommClause{
+			Comm: &ast.ExprStmt{X: &ast.UnaryExpr{Op: token.ARROW, X: done}},
+		})
+	}
+	return sel
+}
+
+// commChan returns the channel expression of the communication
+// comm of a select case, or nil for the default clause.
+func commChan(comm ast.Stmt) ast.Expr {
+	var x ast.Expr
+	switch comm := comm.(type) {
+	case *ast.SendStmt:
+		return comm.Chan
+	case *ast.ExprStmt:
+		x = comm.X
+	case *ast.AssignStmt:
+		x = comm.Rhs[0]
+	}
+	if recv, ok := ast.Unparen(x).(*ast.UnaryExpr); ok && recv.Op == token.ARROW {
+		return recv.X
+	}
+	return nil
+}
+
+// varsInScope returns the variables of pkg in scope at pos, sorted
+// by name. Shadowed variables and variables declared after pos in
+// a local scope are omitted.
+func varsInScope(pkg *types.Package, pos token.Pos) []*types.Var {
+	seen := make(map[string]bool)
+	var vars []*types.Var
+	for s := pkg.Scope().Innermost(pos); s != nil && s != types.Universe; s = s.Parent() {
+		for _, name := range s.Names() {
+			obj := s.Lookup(name)
+			if s != pkg.Scope() && obj.Pos() > pos {
+				continue
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if v, ok := obj.(*types.Var); ok && name != "_" {
+				vars = append(vars, v)
+			}
+		}
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name() < vars[j].Name() })
+	return vars
+}
+
+func isChan(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Chan)
+	return ok
+}
+
+func isContext(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
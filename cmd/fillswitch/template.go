@@ -0,0 +1,284 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Templates for the bodies of added case clauses.
+const (
+	bodyEmpty = ""      // leave the body empty
+	bodyPanic = "panic" // panic("unhandled X")
+	bodyError = "error" // return ..., fmt.Errorf("unexpected %v", x)
+	bodyTODO  = "todo"  // TODO comment at the end of the case line
+	bodyCopy  = "copy"  // copy of the body of the last existing case clause
+)
+
+func validBody(body string) bool {
+	switch body {
+	case bodyEmpty, bodyPanic, bodyError, bodyTODO, bodyCopy:
+		return true
+	default:
+		return false
+	}
+}
+
+// additions collects what the code added to a statement requires
+// besides the statement itself.
+type additions struct {
+	comments map[*ast.CaseClause]string // comments at the end of the lines of case clauses
+	imports  []string                   // paths of the packages to import
+}
+
+func newAdditions() *additions {
+	return &additions{comments: make(map[*ast.CaseClause]string)}
+}
+
+func (a *additions) addImport(path string) {
+	for _, p := range a.imports {
+		if p == path {
+			return
+		}
+	}
+	a.imports = append(a.imports, path)
+}
+
+// fillBodies fills the bodies of the case clauses body.List[added:]
+// using the template opts.body and appends a default clause if
+// requested. x is the value switched over.
+func fillBodies(pkg *packages.Package, f *ast.File, swtch ast.Stmt, body *ast.BlockStmt, added int, x ast.Expr, opts options, add *additions) error {
+	// Type switches report the dynamic type of the unhandled value.
+	verb := "%v"
+	if _, ok := swtch.(*ast.TypeSwitchStmt); ok {
+		verb = "%T"
+	}
+
+	var last *ast.CaseClause
+	for _, stmt := range body.List[:added] {
+		if cc := stmt.(*ast.CaseClause); cc.List != nil && len(cc.Body) > 0 {
+			last = cc
+		}
+	}
+
+	for _, stmt := range body.List[added:] {
+		cc := stmt.(*ast.CaseClause)
+		switch opts.body {
+		case bodyEmpty:
+			// nothing to do
+		case bodyPanic:
+			cc.Body = []ast.Stmt{panicStmt(stringLit("unhandled " + exprsString(cc.List)))}
+		case bodyError:
+			ret, err := errorReturn(pkg, f, swtch, "unexpected "+verb, x)
+			if err != nil {
+				return err
+			}
+			cc.Body = []ast.Stmt{ret}
+			add.addImport("fmt")
+		case bodyTODO:
+			add.comments[cc] = "// TODO: handle " + exprsString(cc.List)
+		case bodyCopy:
+			if last != nil {
+				var err error
+				if cc.Body, err = copyBody(pkg.Fset, last, cc); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown case body template %q", opts.body)
+		}
+	}
+
+	if !opts.withDefault {
+		return nil
+	}
+	for _, stmt := range body.List {
+		if stmt.(*ast.CaseClause).List == nil {
+			return nil // there is already a default clause
+		}
+	}
+	body.List = append(body.List, &ast.CaseClause{
+		Body: []ast.Stmt{panicStmt(sprintf("unexpected "+verb, x))},
+	})
+	add.addImport("fmt")
+	return nil
+}
+
+// copyBody returns a copy of the body of the case clause from for the added
+// case clause to, without a trailing fallthrough statement, since the added
+// clauses come last. If no statement is left, the body panics instead.
+func copyBody(fset *token.FileSet, from, to *ast.CaseClause) ([]ast.Stmt, error) {
+	stmts := from.Body
+	if fallsThrough(from) {
+		stmts = stmts[:len(stmts)-1]
+	}
+	if len(stmts) == 0 {
+		return []ast.Stmt{panicStmt(stringLit("unhandled " + exprsString(to.List)))}, nil
+	}
+
+	// The statements are printed and parsed again to get new nodes.
+	var buf bytes.Buffer
+	buf.WriteString("package p\n\nfunc _() {\n")
+	for _, stmt := range stmts {
+		if err := format.Node(&buf, fset, stmt); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	f, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decls[0].(*ast.FuncDecl).Body.List, nil
+}
+
+// addComments adds comments to the end of the lines of the case clauses
+// of the switch statement n, whose formatted code is code. The synthesized
+// clauses have no positions, so the code is parsed again to get them.
+func addComments(n ast.Node, code string, comments map[*ast.CaseClause]string) (string, error) {
+	var clauses []ast.Stmt
+	switch n := n.(type) {
+	case *ast.SwitchStmt:
+		clauses = n.Body.List
+	case *ast.TypeSwitchStmt:
+		clauses = n.Body.List
+	default:
+		return code, nil
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n\nfunc _() {\n"+code+"\n}\n", 0)
+	if err != nil {
+		return "", err
+	}
+	stmt := f.Decls[0].(*ast.FuncDecl).Body.List[0]
+	var parsed []ast.Stmt
+	switch stmt := stmt.(type) {
+	case *ast.SwitchStmt:
+		parsed = stmt.Body.List
+	case *ast.TypeSwitchStmt:
+		parsed = stmt.Body.List
+	}
+	for i, cc := range clauses {
+		if text, ok := comments[cc.(*ast.CaseClause)]; ok {
+			f.Comments = append(f.Comments, &ast.CommentGroup{
+				List: []*ast.Comment{{Slash: parsed[i].(*ast.CaseClause).Colon + 1, Text: text}},
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, &printer.CommentedNode{Node: stmt, Comments: f.Comments}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func exprsString(exprs []ast.Expr) string {
+	strs := make([]string, len(exprs))
+	for i, e := range exprs {
+		strs[i] = types.ExprString(e)
+	}
+	return strings.Join(strs, ", ")
+}
+
+func stringLit(s string) ast.Expr {
+	return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(s)}
+}
+
+func panicStmt(arg ast.Expr) ast.Stmt {
+	return &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  ast.NewIdent("panic"),
+		Args: []ast.Expr{arg},
+	}}
+}
+
+// sprintf returns the expression fmt.Sprintf(format, x).
+func sprintf(format string, x ast.Expr) ast.Expr {
+	return fmtCall("Sprintf", format, x)
+}
+
+func fmtCall(fun, format string, x ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent("fmt"), Sel: ast.NewIdent(fun)},
+		Args: []ast.Expr{stringLit(format), x},
+	}
+}
+
+// errorReturn returns a return statement returning fmt.Errorf(format, x)
+// and zero values for the other results of the function enclosing swtch.
+func errorReturn(pkg *packages.Package, f *ast.File, swtch ast.Stmt, format string, x ast.Expr) (ast.Stmt, error) {
+	sig := enclosingSignature(pkg.TypesInfo, f, swtch)
+	if sig == nil {
+		return nil, errors.New("switch statement is not inside a function")
+	}
+	res := sig.Results()
+	if res.Len() == 0 || !types.Identical(res.At(res.Len()-1).Type(), types.Universe.Lookup("error").Type()) {
+		return nil, errors.New("enclosing function does not return an error")
+	}
+
+	ret := &ast.ReturnStmt{}
+	for i := 0; i < res.Len()-1; i++ {
+		ret.Results = append(ret.Results, zeroValue(pkg.Types, res.At(i).Type()))
+	}
+	ret.Results = append(ret.Results, fmtCall("Errorf", format, x))
+	return ret, nil
+}
+
+func enclosingSignature(info *types.Info, f *ast.File, n ast.Node) *types.Signature {
+	path, _ := astutil.PathEnclosingInterval(f, n.Pos(), n.End())
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			sig, _ := info.TypeOf(n).(*types.Signature)
+			return sig
+		case *ast.FuncDecl:
+			if obj := info.Defs[n.Name]; obj != nil {
+				sig, _ := obj.Type().(*types.Signature)
+				return sig
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func zeroValue(pkg *types.Package, typ types.Type) ast.Expr {
+	if _, ok := typ.(*types.TypeParam); ok {
+		// *new(T) is the zero value of any type T.
+		return &ast.StarExpr{X: &ast.CallExpr{
+			Fun:  ast.NewIdent("new"),
+			Args: []ast.Expr{ast.NewIdent(typeString(pkg, typ))},
+		}}
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return ast.NewIdent("false")
+		case t.Info()&types.IsString != 0:
+			return &ast.BasicLit{Kind: token.STRING, Value: `""`}
+		case t.Info()&types.IsNumeric != 0:
+			return &ast.BasicLit{Kind: token.INT, Value: "0"}
+		}
+	case *types.Struct, *types.Array:
+		return &ast.CompositeLit{Type: ast.NewIdent(typeString(pkg, typ))}
+	}
+	return ast.NewIdent("nil")
+}
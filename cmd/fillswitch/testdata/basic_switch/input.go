@@ -0,0 +1,11 @@
+package p
+
+const (
+	a = 1
+	b = 2
+)
+
+func test(n int) {
+	switch n {
+	}
+}
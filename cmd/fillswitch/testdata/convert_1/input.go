@@ -0,0 +1,20 @@
+package p
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+	KindC
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindA:
+		return "a"
+	case KindB:
+		return "b"
+	default:
+		return "?"
+	}
+}
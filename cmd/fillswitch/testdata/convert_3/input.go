@@ -0,0 +1,19 @@
+package p
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+	KindC
+)
+
+// KindNames maps kinds to their names.
+var KindNames = map[Kind]string{
+	KindA: "a",
+	KindB: "b",
+}
+
+func (k Kind) String() string {
+	return KindNames[k]
+}
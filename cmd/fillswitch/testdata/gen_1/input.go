@@ -0,0 +1,10 @@
+package p
+
+// Protocol is a network protocol.
+type Protocol uint8
+
+const (
+	ProtoTCP Protocol = iota + 1
+	ProtoUDP
+	ProtoHTTPServer
+)
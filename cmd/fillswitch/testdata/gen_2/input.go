@@ -0,0 +1,10 @@
+package p
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+)
+
+func (l Level) String() string { return "" }
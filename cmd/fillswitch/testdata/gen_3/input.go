@@ -0,0 +1,8 @@
+package p
+
+type Method int
+
+const (
+	MethodGet Method = iota
+	MethodGET
+)
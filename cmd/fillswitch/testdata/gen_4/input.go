@@ -0,0 +1,10 @@
+package p
+
+type Stage int
+
+const (
+	Start Stage = iota
+	Stop
+)
+
+var s = Start
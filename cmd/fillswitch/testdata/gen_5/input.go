@@ -0,0 +1,12 @@
+package p
+
+import f "fmt"
+
+type Stage int
+
+const (
+	Start Stage = iota
+	Stop
+)
+
+var s = f.Sprint(Start)
@@ -0,0 +1,18 @@
+package p
+
+import "context"
+
+var global = make(chan struct{})
+
+type event struct{ id int }
+
+func run(ctx context.Context, in <-chan event, out chan<- *event, errs chan<- error) {
+	done := make(chan bool)
+	global := 1
+	_ = global
+	select {
+	case <-done:
+	}
+	later := make(chan int)
+	_ = later
+}
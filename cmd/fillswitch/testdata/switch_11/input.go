@@ -0,0 +1,20 @@
+package p
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+)
+
+func test(l Level) string {
+	s := ""
+	switch l {
+	case Debug:
+		s += "debug"
+		fallthrough
+	case Info:
+	}
+	return s
+}
@@ -0,0 +1,18 @@
+package p
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+)
+
+func test(l Level) string {
+	switch l {
+	case Info:
+		fallthrough
+	case Debug:
+	}
+	return ""
+}
@@ -0,0 +1,18 @@
+package p
+
+import "fmt"
+
+type Op int
+
+const (
+	Add Op = iota
+	Sub
+)
+
+func apply[T any](op Op, v T) (T, error) {
+	switch op {
+	case Add:
+		return v, nil
+	}
+	return v, fmt.Errorf("unreachable")
+}
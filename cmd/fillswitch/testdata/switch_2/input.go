@@ -0,0 +1,21 @@
+package p
+
+type Status int
+
+const (
+	StatusUnknown Status = iota
+	StatusSuccess
+	// Deprecated: Use StatusFailed instead.
+	StatusFailure
+
+	StatusOK     = StatusSuccess
+	StatusFailed = StatusFailure
+)
+
+var DefaultStatus = StatusUnknown
+
+func test(s Status) {
+	switch s {
+	case StatusOK:
+	}
+}
@@ -0,0 +1,15 @@
+package p
+
+type Color string
+
+const (
+	Red   Color = "red"
+	Green Color = "green"
+)
+
+var Custom = Color("custom")
+
+func test(c Color) {
+	switch c {
+	}
+}
@@ -0,0 +1,21 @@
+package p
+
+import "go/ast"
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+)
+
+const (
+	maxKinds   = 2
+	defaultInt = 0
+	objKind    = ast.Var
+)
+
+func test(k Kind) {
+	switch k {
+	}
+}
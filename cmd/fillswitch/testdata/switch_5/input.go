@@ -0,0 +1,15 @@
+package p
+
+type Mode int
+
+const (
+	Read Mode = iota
+	Write
+	Exec
+)
+
+func test(m Mode) {
+	switch m {
+	case Read:
+	}
+}
@@ -0,0 +1,20 @@
+package p
+
+import "fmt"
+
+type Op int
+
+const (
+	Add Op = iota
+	Sub
+)
+
+type Point struct{ X, Y int }
+
+func apply(op Op) (int, Point, error) {
+	switch op {
+	case Add:
+		return 1, Point{}, nil
+	}
+	return 0, Point{}, fmt.Errorf("unreachable")
+}
@@ -0,0 +1,23 @@
+package p
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+	Fatal
+)
+
+func test(l Level) string {
+	switch l {
+	case Error, Warn:
+		return "bad"
+	case Removed:
+	case Debug:
+	case Info, Warn:
+		return "dup"
+	}
+	return ""
+}
@@ -0,0 +1,16 @@
+package p
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+func test(c Color) {
+	switch c {
+	case Red:
+		println("red")
+	}
+}
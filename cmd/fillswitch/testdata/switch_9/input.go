@@ -0,0 +1,17 @@
+package p
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+)
+
+func test(l Level) string {
+	switch l {
+	case Removed:
+		return "gone"
+	case Debug, Info:
+	}
+	return ""
+}
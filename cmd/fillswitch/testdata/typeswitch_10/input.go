@@ -0,0 +1,24 @@
+package p
+
+type Shape interface{ shape() }
+
+type Circle struct{}
+
+func (Circle) shape() {}
+
+type Square struct{}
+
+func (Square) shape() {}
+
+type Triangle struct{}
+
+func (Triangle) shape() {}
+
+func test(s Shape) {
+	switch s.(type) {
+	case Square:
+	case Hexagon:
+	case nil:
+	case Square:
+	}
+}
@@ -3,7 +3,7 @@ package p
 import (
 	"io"
 
-	_ "github.com/davidrjenni/reftools/cmd/fillswitch/testdata/typeswitch_5/internal/foo"
+	"github.com/shiba6v/reftools/cmd/fillswitch/testdata/typeswitch_5/internal/foo"
 )
 
 func test(r io.Reader) {
@@ -21,3 +21,5 @@ type myReadWriter interface {
 	Read(p []byte) (int, error)
 	Writer(p []byte) (int, error)
 }
+
+var _ io.Reader = foo.NopReader2{}
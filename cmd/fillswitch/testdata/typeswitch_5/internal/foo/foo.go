@@ -1,5 +1,9 @@
 package foo
 
+import "io"
+
+var _ io.Reader = (*NopReader1)(nil)
+
 type NopReader1 struct{}
 
 func (r *NopReader1) Read(p []byte) (int, error) {
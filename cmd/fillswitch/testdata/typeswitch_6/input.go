@@ -0,0 +1,14 @@
+package p
+
+import "github.com/shiba6v/reftools/cmd/fillswitch/testdata/typeswitch_6/shapes"
+
+// Ellipse implements the sealed interface shapes.Shape
+// through the promoted method of the embedded shapes.Circle.
+type Ellipse struct {
+	shapes.Circle
+}
+
+func test(s shapes.Shape) {
+	switch s.(type) {
+	}
+}
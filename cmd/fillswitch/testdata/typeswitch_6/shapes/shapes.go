@@ -0,0 +1,13 @@
+package shapes
+
+type Shape interface {
+	area() float64
+}
+
+type Circle struct{}
+
+func (Circle) area() float64 { return 0 }
+
+type Rect struct{}
+
+func (*Rect) area() float64 { return 0 }
@@ -0,0 +1,19 @@
+package p
+
+type Node interface{ node() }
+
+type Leaf struct{}
+
+func (Leaf) node() {}
+
+type Pair struct{ L, R Node }
+
+func (Pair) node() {}
+
+func count(n Node) int {
+	switch n.(type) {
+	case Leaf:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,19 @@
+package p
+
+type Celsius float64
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Value interface {
+	Number | ~string | Celsius
+}
+
+func describe[T Value](v T) string {
+	switch any(v).(type) {
+	case string:
+		return "string"
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package p
+
+type Shape interface{ Area() float64 }
+
+type Square struct{ Side float64 }
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Box[T any] struct{ Shape T }
+
+func (b *Box[T]) Area() float64 { return 0 }
+
+var (
+	small = &Box[int]{}
+	named = &Box[Square]{}
+)
+
+func area(s Shape) {
+	switch s.(type) {
+	case *Box[int]:
+	}
+}
@@ -171,10 +171,26 @@ func writeType(buf *bytes.Buffer, pkg *types.Package, typ types.Type, visited []
 		}
 
 	case *types.Named:
-		if pkg != t.Obj().Pkg() && t.Obj().Pkg() != nil {
-			buf.WriteString(fmt.Sprintf("%s.%s", t.Obj().Pkg().Name(), t.Obj().Name()))
-		} else {
-			buf.WriteString(t.Obj().Name())
+		writeTypeName(buf, pkg, t.Obj())
+		writeTypeList(buf, pkg, t.TypeArgs(), visited)
+
+	case *types.Alias:
+		writeTypeName(buf, pkg, t.Obj())
+		writeTypeList(buf, pkg, t.TypeArgs(), visited)
+
+	case *types.TypeParam:
+		buf.WriteString(t.Obj().Name())
+
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(" | ")
+			}
+			term := t.Term(i)
+			if term.Tilde() {
+				buf.WriteByte('~')
+			}
+			writeType(buf, pkg, term.Type(), visited)
 		}
 
 	default:
@@ -183,6 +199,29 @@ func writeType(buf *bytes.Buffer, pkg *types.Package, typ types.Type, visited []
 	}
 }
 
+func writeTypeName(buf *bytes.Buffer, pkg *types.Package, obj *types.TypeName) {
+	if pkg != obj.Pkg() && obj.Pkg() != nil {
+		buf.WriteString(fmt.Sprintf("%s.%s", obj.Pkg().Name(), obj.Name()))
+	} else {
+		buf.WriteString(obj.Name())
+	}
+}
+
+// writeTypeList writes the type arguments of an instantiated type.
+func writeTypeList(buf *bytes.Buffer, pkg *types.Package, list *types.TypeList, visited []types.Type) {
+	if list.Len() == 0 {
+		return
+	}
+	buf.WriteByte('[')
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeType(buf, pkg, list.At(i), visited)
+	}
+	buf.WriteByte(']')
+}
+
 func writeTuple(buf *bytes.Buffer, pkg *types.Package, tup *types.Tuple, variadic bool, visited []types.Type) {
 	buf.WriteByte('(')
 	if tup != nil {
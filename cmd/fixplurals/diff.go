@@ -0,0 +1,185 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// context is the number of unchanged lines around the changes of a hunk.
+const context = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is one line of an edit script.
+type op struct {
+	kind opKind
+	line string
+}
+
+// writeDiff writes the changes from before to after of the file
+// filename as unified diff to w, similar to the output of gofmt -d.
+func writeDiff(w io.Writer, filename string, before, after []byte) error {
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "diff %s.orig %s\n--- %s.orig\n+++ %s\n", filename, filename, filename, filename)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the changes are separated
+		// by at most 2*context unchanged lines.
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(ops))
+
+		a, b := lineNumbers(ops[:start])
+		var la, lb int
+		for _, o := range ops[start:end] {
+			if o.kind != opInsert {
+				la++
+			}
+			if o.kind != opDelete {
+				lb++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(a, la), hunkRange(b, lb))
+		for _, o := range ops[start:end] {
+			buf.WriteByte(" -+"[o.kind])
+			buf.WriteString(o.line)
+			if len(o.line) == 0 || o.line[len(o.line)-1] != '\n' {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// lineNumbers returns the number of lines of
+// the old and the new file covered by ops.
+func lineNumbers(ops []op) (a, b int) {
+	for _, o := range ops {
+		if o.kind != opInsert {
+			a++
+		}
+		if o.kind != opDelete {
+			b++
+		}
+	}
+	return a, b
+}
+
+// hunkRange formats the range of n lines following
+// the first lines of a file for a hunk header.
+func hunkRange(first, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", first)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", first+1)
+	}
+	return fmt.Sprintf("%d,%d", first+1, n)
+}
+
+func splitLines(b []byte) []string {
+	var lines []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		lines = append(lines, string(b[:i]))
+		b = b[i:]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script transforming a into b,
+// computed with the greedy algorithm of Myers, "An O(ND) Difference
+// Algorithm and Its Variations".
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		// trace[d] holds the furthest reaching paths of length d-1.
+		trace = append(trace, append([]int(nil), v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1] // insertion
+			} else {
+				x = v[offset+k-1] + 1 // deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// Walk the trace backwards to recover the edit script.
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{opEqual, a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, op{opInsert, b[y-1]})
+			} else {
+				ops = append(ops, op{opDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
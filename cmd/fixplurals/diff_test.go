@@ -0,0 +1,123 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	tests := [...]struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "no changes",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "one change",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: `@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name:   "separate hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: `@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++twelve
+`,
+		},
+		{
+			name:   "merged hunk",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\neight\n",
+			want: `@@ -1,8 +1,8 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++eight
+`,
+		},
+		{
+			name:   "insertion and deletion",
+			before: "a\nb\nc\n",
+			after:  "a\nc\nd\n",
+			want: `@@ -1,3 +1,3 @@
+ a
+-b
+ c
++d
+`,
+		},
+		{
+			name:   "empty file",
+			before: "",
+			after:  "a\n",
+			want: `@@ -0,0 +1 @@
++a
+`,
+		},
+		{
+			name:   "no newline at end of file",
+			before: "a\nb",
+			after:  "a\nc",
+			want: `@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := writeDiff(&buf, "f.go", []byte(test.before), []byte(test.after)); err != nil {
+			t.Fatalf("%q: %v", test.name, err)
+		}
+		const header = "diff f.go.orig f.go\n--- f.go.orig\n+++ f.go\n"
+		got := buf.String()
+		if !strings.HasPrefix(got, header) {
+			t.Fatalf("%q: got %q, want the header %q", test.name, got, header)
+		}
+		if got = strings.TrimPrefix(got, header); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
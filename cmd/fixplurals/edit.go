@@ -0,0 +1,113 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// edit replaces the bytes src[start:end] of a file by text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// span is the range src[start:end] of a file.
+type span struct {
+	start, end int
+}
+
+// applyEdits returns a copy of src with the edits applied. Edits
+// overlapping a preceding edit are dropped, e.g. the edits of a
+// function type inside the type of a struct field which is merged
+// with the next field. If src is formatted, the field lists of structs
+// given by fields, from the opening to the closing brace, are formatted
+// after the edits, such that their fields are realigned. All other bytes
+// outside the edits are kept.
+func applyEdits(src []byte, edits []edit, fields []span) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var (
+		buf     bytes.Buffer
+		applied []edit
+	)
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		buf.Write(src[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+		applied = append(applied, e)
+	}
+	buf.Write(src[last:])
+	out := buf.Bytes()
+
+	if len(fields) == 0 {
+		return out
+	}
+	if formatted, err := format.Source(src); err != nil || !bytes.Equal(formatted, src) {
+		return out
+	}
+
+	// Map the field lists to the offsets after the edits and
+	// format the outermost ones, starting at the end of the file.
+	sort.Slice(fields, func(i, j int) bool { return fields[i].start < fields[j].start })
+	var outer []span
+	for _, s := range fields {
+		if len(outer) > 0 && s.start < outer[len(outer)-1].end {
+			continue
+		}
+		outer = append(outer, s)
+	}
+	for i := len(outer) - 1; i >= 0; i-- {
+		start, end := newOffset(applied, outer[i].start), newOffset(applied, outer[i].end)
+		code, ok := formatFields(out[start:end], indent(out, start))
+		if !ok {
+			continue
+		}
+		out = append(out[:start:start], append([]byte(code), out[end:]...)...)
+	}
+	return out
+}
+
+// newOffset returns the offset of the byte at offset off
+// of the original file after applying the edits applied.
+func newOffset(applied []edit, off int) int {
+	n := off
+	for _, e := range applied {
+		if e.end <= off {
+			n += len(e.text) - (e.end - e.start)
+		}
+	}
+	return n
+}
+
+// formatFields formats the field list fields of a struct, which starts
+// on a line indented by indent. It reports false if fields is invalid.
+func formatFields(fields []byte, indent string) (string, bool) {
+	const prefix = "package p\n\ntype _ struct "
+	out, err := format.Source([]byte(prefix + string(fields) + "\n"))
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(string(out), prefix), "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// indent returns the indentation of the line of src containing offset.
+func indent(src []byte, offset int) string {
+	line := src[bytes.LastIndexByte(src[:offset], '\n')+1:]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	tests := [...]struct {
+		name   string
+		src    string
+		edits  [][2]string // edits replacing the first occurrence of [0] by [1]
+		fields []string    // field lists to realign, given by their source
+		want   string
+	}{
+		{
+			name:  "edits in any order",
+			src:   "package p\n\nvar a, b = 1, 2\n",
+			edits: [][2]string{{"2\n", "two\n"}, {"1,", "one,"}},
+			want:  "package p\n\nvar a, b = one, two\n",
+		},
+		{
+			name:  "overlapping edits",
+			src:   "package p\n\nvar a = 1 + 2\n",
+			edits: [][2]string{{"1 + 2", "3"}, {"2", "4"}},
+			want:  "package p\n\nvar a = 3\n",
+		},
+		{
+			name: "realigned struct",
+			src: `package p
+
+type T struct {
+	a      string
+	b      string
+	longer int // comment
+}
+
+const (
+	x    = 1 // x
+	long = 2 // long
+)
+`,
+			edits: [][2]string{{"      string\n\tb", ", b"}},
+			fields: []string{`{
+	a      string
+	b      string
+	longer int // comment
+}`},
+			want: `package p
+
+type T struct {
+	a, b   string
+	longer int // comment
+}
+
+const (
+	x    = 1 // x
+	long = 2 // long
+)
+`,
+		},
+		{
+			name: "indented struct",
+			src: `package p
+
+func f() {
+	var v struct {
+		a string
+		b string
+		c int
+	}
+	_ = v
+}
+`,
+			edits: [][2]string{{" string\n\t\tb", ", b"}},
+			fields: []string{`{
+		a string
+		b string
+		c int
+	}`},
+			want: `package p
+
+func f() {
+	var v struct {
+		a, b string
+		c    int
+	}
+	_ = v
+}
+`,
+		},
+		{
+			name: "unformatted file",
+			src: `package p
+
+type T struct {
+	a string
+	b string
+	c    int
+}
+`,
+			edits: [][2]string{{" string\n\tb", ", b"}},
+			fields: []string{`{
+	a string
+	b string
+	c    int
+}`},
+			want: `package p
+
+type T struct {
+	a, b string
+	c    int
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		var edits []edit
+		for _, e := range test.edits {
+			start := strings.Index(test.src, e[0])
+			if start < 0 {
+				t.Fatalf("%q: %q not found", test.name, e[0])
+			}
+			edits = append(edits, edit{start: start, end: start + len(e[0]), text: e[1]})
+		}
+		var fields []span
+		for _, f := range test.fields {
+			start := strings.Index(test.src, f)
+			if start < 0 {
+				t.Fatalf("%q: field list %q not found", test.name, f)
+			}
+			fields = append(fields, span{start: start, end: start + len(f)})
+		}
+		if got := string(applyEdits([]byte(test.src), edits, fields)); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
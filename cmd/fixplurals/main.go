@@ -3,7 +3,7 @@
 // license that can be found in the LICENSE file.
 
 // Fixplurals removes redundant parameter and result types
-// from function signatures.
+// from function signatures and redundant field types from structs.
 //
 // For example, the following function signature:
 //	func fun(a string, b string) (c string, d string)
@@ -11,116 +11,305 @@
 //	func fun(a, b string) (c, d string)
 // after applying fixplurals.
 //
+// Function declarations and literals, function types and the methods
+// of interfaces are rewritten. Consecutive struct fields are merged
+// only if none of them has a tag or a comment.
+//
+// Only the changed parts of the files are rewritten. Types separated by
+// comments are left alone, such that no comment is lost. In files formatted
+// with gofmt, the fields of the changed structs are realigned.
+//
 // Usage:
 //
-// 	% fixplurals [-dry] packages
+// 	% fixplurals [-d] [-l] [-expand] [-tags=<build tags>] [-platforms=<GOOS/GOARCH,...>] packages
 //
 // Flags:
 //
-// -dry: changes are printed to stdout instead of rewriting the source files
+// -d:         print a unified diff of the changes instead of rewriting the source files
+//
+// -l:         list the files which would be changed instead of rewriting them
+//
+// -dry:       same as -d, but the exit status is 0 as before; deprecated
+//
+// -expand:    instead of removing redundant types, give each parameter, result
+//             and struct field its own type, e.g. func fun(a string, b string)
+//
+// -tags:      a list of build tags to consider satisfied during the build
+//
+// -platforms: a comma-separated list of GOOS/GOARCH pairs, e.g.
+//             linux/amd64,windows/amd64; the packages are loaded for each
+//             of them, such that files for other platforms are fixed as well
+//
+// The packages are given as patterns of the go command, e.g. ./...,
+// and default to the package in the current directory.
+//
+// With -d or -l, fixplurals exits with status 1 if any file would be
+// changed, such that it can be used to check the code in CI. -dry prints
+// the same diff, but keeps exiting with status 0 for existing scripts.
 //
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/format"
-	"go/parser"
 	"go/token"
 	"go/types"
+	"io"
 	"io/ioutil"
 	"log"
+	"os"
+	"strings"
 
-	"github.com/kisielk/gotool"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/packages"
 )
 
+// options configures how packages are fixed.
+type options struct {
+	expand bool // expand grouped names instead of grouping them
+	diff   bool // print a diff instead of rewriting the files
+	list   bool // list the changed files instead of rewriting them
+}
+
 func main() {
 	log.SetFlags(0)
 	log.SetPrefix("fixplurals: ")
 
-	dryRun := flag.Bool("dry", false, "dry run: print changes to stdout")
+	var btags buildutil.TagsFlag
+	diff := flag.Bool("d", false, "print a unified diff instead of rewriting the files")
+	list := flag.Bool("l", false, "list the files which would be changed instead of rewriting them")
+	dryRun := flag.Bool("dry", false, "same as -d, but exit with status 0, deprecated")
+	expand := flag.Bool("expand", false, "expand grouped names instead, giving each its own type")
+	platforms := flag.String("platforms", "", "comma-separated list of GOOS/GOARCH pairs to load the packages for")
+	flag.Var(&btags, "tags", buildutil.TagsFlagDoc)
 	flag.Parse()
 
-	importPaths := gotool.ImportPaths(flag.Args())
-	if len(importPaths) == 0 {
-		return
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	opts := options{expand: *expand, diff: *diff || *dryRun, list: *list}
+
+	// Files are fixed only once, even if they are part of
+	// several packages or platform configurations.
+	done := make(map[string]bool)
+	changed := false
+	for _, platform := range strings.Split(*platforms, ",") {
+		env, err := platformEnv(platform)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pkgs, err := load(patterns, btags, env)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, pkg := range pkgs {
+			ch, err := fixPackage(pkg, done, opts, os.Stdout)
+			if err != nil {
+				log.Fatal(err)
+			}
+			changed = changed || ch
+		}
 	}
+	// -dry keeps its former exit status 0.
+	if changed && (*diff || *list) {
+		os.Exit(1)
+	}
+}
 
-	conf := loader.Config{ParserMode: parser.ParseComments}
-	for _, importPath := range importPaths {
-		conf.ImportWithTests(importPath)
+// platformEnv returns the environment to load packages
+// for platform, given as GOOS/GOARCH, or the default
+// environment if platform is empty.
+func platformEnv(platform string) ([]string, error) {
+	env := os.Environ()
+	if platform == "" {
+		return env, nil
 	}
+	i := strings.Index(platform, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid platform %q, want GOOS/GOARCH", platform)
+	}
+	return append(env, "GOOS="+platform[:i], "GOARCH="+platform[i+1:]), nil
+}
 
-	prog, err := conf.Load()
+func load(patterns, tags, env []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:       packages.LoadSyntax,
+		Tests:      true,
+		BuildFlags: []string{"-tags", strings.Join(tags, ",")},
+		Env:        env,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	for _, pkg := range prog.InitialPackages() {
-		for _, file := range pkg.Files {
-			filename := conf.Fset.File(file.Pos()).Name()
-			ast.Inspect(file, func(node ast.Node) bool {
-				if f, ok := node.(*ast.FuncDecl); ok {
-					var before []byte
-					if *dryRun {
-						if before, err = printNode(f.Type, prog.Fset); err != nil {
-							log.Fatal(err)
-						}
-					}
-					ch1 := fixPlurals(pkg.Info, f.Type.Params)
-					ch2 := fixPlurals(pkg.Info, f.Type.Results)
-					if ch1 || ch2 {
-						if *dryRun {
-							after, err := printNode(f.Type, prog.Fset)
-							if err != nil {
-								log.Fatal(err)
-							}
-							fmt.Printf("--- %s\nbefore: %s\nafter:  %s\n\n", filename, string(before), string(after))
-						} else {
-							src, err := printNode(file, prog.Fset)
-							if err != nil {
-								log.Fatal(err)
-							}
-							if err := ioutil.WriteFile(filename, src, 0644); err != nil {
-								log.Fatal(err)
-							}
-						}
-					}
-				}
-				return true
-			})
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("packages contain errors")
+	}
+	return pkgs, nil
+}
+
+// fixPackage fixes the files of pkg not contained in done and
+// reports whether any of them changed. Unless opts.diff or opts.list
+// is set, the changed files are rewritten.
+func fixPackage(pkg *packages.Package, done map[string]bool, opts options, out io.Writer) (changed bool, err error) {
+	for _, file := range pkg.Syntax {
+		filename := pkg.Fset.File(file.Pos()).Name()
+		if done[filename] {
+			continue
+		}
+		done[filename] = true
+
+		before, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return false, err
+		}
+		f := &fixer{fset: pkg.Fset, info: pkg.TypesInfo, file: file, src: before}
+		fixFields := f.fixPlurals
+		if opts.expand {
+			fixFields = f.expandPlurals
+		}
+		fixFile(file, fixFields)
+		if len(f.edits) == 0 {
+			continue
+		}
+		changed = true
+
+		after := applyEdits(before, f.edits, f.structs)
+		switch {
+		case opts.list:
+			fmt.Fprintln(out, filename)
+		case opts.diff:
+			err = writeDiff(out, filename, before, after)
+		default:
+			err = ioutil.WriteFile(filename, after, 0644)
+		}
+		if err != nil {
+			return false, err
 		}
 	}
+	return changed, nil
 }
 
-func printNode(n ast.Node, fset *token.FileSet) ([]byte, error) {
-	var buf bytes.Buffer
-	if err := format.Node(&buf, fset, n); err != nil {
-		return nil, err
+// fixFile applies fixFields to the parameters and results of all
+// function types and the fields of all struct types in root.
+func fixFile(root ast.Node, fixFields func(fields *ast.FieldList, isStruct bool)) {
+	ast.Inspect(root, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncType:
+			fixFields(n.Params, false)
+			fixFields(n.Results, false)
+		case *ast.StructType:
+			fixFields(n.Fields, true)
+		}
+		return true
+	})
+}
+
+// fixer collects the edits fixing a file.
+type fixer struct {
+	fset    *token.FileSet
+	info    *types.Info
+	file    *ast.File
+	src     []byte // content of the file
+	edits   []edit
+	structs []span // field lists of the edited structs
+}
+
+// fixPlurals merges consecutive fields of identical types in the
+// parameters or results of a function or the fields of a struct,
+// e.g. a string, b string into a, b string.
+func (f *fixer) fixPlurals(fields *ast.FieldList, isStruct bool) {
+	if fields == nil {
+		return
 	}
-	return buf.Bytes(), nil
+	n := len(f.edits)
+	for i := 0; i+1 < len(fields.List); i++ {
+		field, next := fields.List[i], fields.List[i+1]
+		if !mergeable(field) || !mergeable(next) || !types.Identical(f.info.Types[field.Type].Type, f.info.Types[next.Type].Type) {
+			continue
+		}
+		// Replace the type of field and the separator by a comma.
+		f.replace(field.Names[len(field.Names)-1].End(), next.Names[0].Pos(), ", ")
+	}
+	f.realign(fields, isStruct, n)
 }
 
-func fixPlurals(info types.Info, fields *ast.FieldList) (changed bool) {
-	if fields == nil || fields.List == nil {
+// expandPlurals splits fields declaring several names into one field
+// per name, e.g. a, b string into a string, b string. The fields share
+// the type and tag; the doc comment is kept on the first field and the
+// line comment on the last one.
+func (f *fixer) expandPlurals(fields *ast.FieldList, isStruct bool) {
+	if fields == nil {
 		return
 	}
+	n := len(f.edits)
+	for _, field := range fields.List {
+		if len(field.Names) < 2 {
+			continue
+		}
+		typ := " " + f.expanded(field.Type)
+		if field.Tag != nil {
+			typ += " " + field.Tag.Value
+		}
+		sep := ", "
+		if isStruct {
+			sep = "\n" + f.indent(field.Pos())
+		}
+		for i := 0; i+1 < len(field.Names); i++ {
+			f.replace(field.Names[i].End(), field.Names[i+1].Pos(), typ+sep)
+		}
+	}
+	f.realign(fields, isStruct, n)
+}
+
+// expanded returns the source of the type typ with
+// the fields of the nested types expanded as well.
+func (f *fixer) expanded(typ ast.Expr) string {
+	nested := &fixer{fset: f.fset, info: f.info, file: f.file, src: f.src}
+	fixFile(typ, nested.expandPlurals)
+	start, end := f.fset.Position(typ.Pos()).Offset, f.fset.Position(typ.End()).Offset
+	for i := range nested.edits {
+		nested.edits[i].start -= start
+		nested.edits[i].end -= start
+	}
+	return string(applyEdits(f.src[start:end], nested.edits, nil))
+}
 
-	var prev *ast.Field
-	for i := len(fields.List) - 1; i >= 0; i-- {
-		field := fields.List[i]
-		if i != len(fields.List)-1 && len(field.Names) > 0 && types.Identical(info.Types[prev.Type].Type, info.Types[field.Type].Type) {
-			prev.Names = append(field.Names, prev.Names...)
-			copy(fields.List[i:], fields.List[i+1:])
-			fields.List[len(fields.List)-1] = nil
-			fields.List = fields.List[:len(fields.List)-1]
-			changed = true
-		} else {
-			prev = field
+// realign marks the fields of a struct to be realigned
+// if edits were added since there were n of them.
+func (f *fixer) realign(fields *ast.FieldList, isStruct bool, n int) {
+	if isStruct && len(f.edits) > n {
+		f.structs = append(f.structs, span{
+			start: f.fset.Position(fields.Opening).Offset,
+			end:   f.fset.Position(fields.Closing).Offset + 1,
+		})
+	}
+}
+
+// replace adds an edit replacing the source between start and end
+// by text, unless a comment would be lost.
+func (f *fixer) replace(start, end token.Pos, text string) {
+	for _, c := range f.file.Comments {
+		if c.Pos() < end && start < c.End() {
+			return
 		}
 	}
-	return changed
+	f.edits = append(f.edits, edit{
+		start: f.fset.Position(start).Offset,
+		end:   f.fset.Position(end).Offset,
+		text:  text,
+	})
+}
+
+// indent returns the indentation of the line containing pos.
+func (f *fixer) indent(pos token.Pos) string {
+	return indent(f.src, f.fset.Position(pos).Offset)
+}
+
+// mergeable reports whether field can be merged with a neighbouring
+// field without losing its tag or comments.
+func mergeable(field *ast.Field) bool {
+	return len(field.Names) > 0 && field.Tag == nil && field.Doc == nil && field.Comment == nil
 }
@@ -0,0 +1,319 @@
+// Copyright (c) 2026 shiba6v All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fix fixes the file src and returns the result.
+func fix(t *testing.T, name, src string, expand bool) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%q: %v", name, err)
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatalf("%q: %v", name, err)
+	}
+
+	f := &fixer{fset: fset, info: info, file: file, src: []byte(src)}
+	fixFields := f.fixPlurals
+	if expand {
+		fixFields = f.expandPlurals
+	}
+	fixFile(file, fixFields)
+	return string(applyEdits([]byte(src), f.edits, f.structs))
+}
+
+func TestFixPlurals(t *testing.T) {
+	tests := [...]struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "parameters and results",
+			src: `package p
+
+func f(a int, b int, c string) (d error, e error) { return nil, nil }
+
+func g(a int, b int32) {}
+`,
+			want: `package p
+
+func f(a, b int, c string) (d, e error) { return nil, nil }
+
+func g(a int, b int32) {}
+`,
+		},
+		{
+			name: "function literals and types",
+			src: `package p
+
+type handler func(w int, r int) (n int, err error)
+
+var f = func(a string, b string) func(c int, d int) {
+	return func(c int, d int) {}
+}
+`,
+			want: `package p
+
+type handler func(w, r int) (n int, err error)
+
+var f = func(a, b string) func(c, d int) {
+	return func(c, d int) {}
+}
+`,
+		},
+		{
+			name: "interface methods",
+			src: `package p
+
+type Reader interface {
+	ReadAt(p []byte, q []byte, off int64) (n int, m int)
+}
+`,
+			want: `package p
+
+type Reader interface {
+	ReadAt(p, q []byte, off int64) (n, m int)
+}
+`,
+		},
+		{
+			name: "struct fields",
+			src: `package p
+
+type T struct {
+	a      string
+	b      string
+	longer int // comment
+	c, d   bool
+	e      bool
+	f      func(x int, y int)
+}
+
+var x = 1 // not realigned
+`,
+			want: `package p
+
+type T struct {
+	a, b    string
+	longer  int // comment
+	c, d, e bool
+	f       func(x, y int)
+}
+
+var x = 1 // not realigned
+`,
+		},
+		{
+			name: "tags and comments",
+			src: `package p
+
+type T struct {
+	a int ` + "`json:\"a\"`" + `
+	b int
+	// doc
+	c int
+	d int // line
+	e int
+}
+
+func f(a int /* a */, b int, c int) {}
+`,
+			want: `package p
+
+type T struct {
+	a int ` + "`json:\"a\"`" + `
+	b int
+	// doc
+	c int
+	d int // line
+	e int
+}
+
+func f(a int /* a */, b, c int) {}
+`,
+		},
+		{
+			name: "nested struct",
+			src: `package p
+
+func f() {
+	var v struct {
+		p int
+		q int
+
+		r string
+	}
+	_ = v
+}
+`,
+			want: `package p
+
+func f() {
+	var v struct {
+		p, q int
+
+		r string
+	}
+	_ = v
+}
+`,
+		},
+		{
+			name: "unformatted",
+			src: `package p
+
+type T struct {
+	a string
+	b  string
+	c   int
+}
+`,
+			want: `package p
+
+type T struct {
+	a, b  string
+	c   int
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := fix(t, test.name, test.src, false); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExpandPlurals(t *testing.T) {
+	tests := [...]struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "parameters and results",
+			src: `package p
+
+func f(a, b int, c string) (d, e error) { return nil, nil }
+
+var g = func(a, b string) {}
+
+type I interface {
+	M(p, q []byte) (n, m int)
+}
+`,
+			want: `package p
+
+func f(a int, b int, c string) (d error, e error) { return nil, nil }
+
+var g = func(a string, b string) {}
+
+type I interface {
+	M(p []byte, q []byte) (n int, m int)
+}
+`,
+		},
+		{
+			name: "struct fields",
+			src: `package p
+
+type T struct {
+	// doc
+	a, b   string ` + "`json:\"x\"`" + ` // line
+	longer int
+	c, d   func(x, y int)
+}
+`,
+			want: `package p
+
+type T struct {
+	// doc
+	a      string ` + "`json:\"x\"`" + `
+	b      string ` + "`json:\"x\"`" + ` // line
+	longer int
+	c      func(x int, y int)
+	d      func(x int, y int)
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := fix(t, test.name, test.src, true); got != test.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPlatformEnv(t *testing.T) {
+	env, err := platformEnv("windows/arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(env); n < 2 || env[n-2] != "GOOS=windows" || env[n-1] != "GOARCH=arm64" {
+		t.Errorf("got environment ending in %v, want GOOS=windows GOARCH=arm64", env[max(n-2, 0):])
+	}
+	if _, err := platformEnv("linux"); err == nil {
+		t.Error("expected an error for a platform without GOARCH")
+	}
+}
+
+func TestPlatformsAndTags(t *testing.T) {
+	tests := [...]struct {
+		platforms []string
+		tags      []string
+		want      []string
+	}{
+		{platforms: []string{"linux/amd64"}, want: []string{"common.go", "linux.go"}},
+		{platforms: []string{"linux/amd64", "windows/amd64"}, want: []string{"common.go", "linux.go", "windows.go"}},
+		{platforms: []string{"windows/amd64"}, tags: []string{"extra"}, want: []string{"common.go", "extra.go", "windows.go"}},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		done := make(map[string]bool)
+		for _, platform := range test.platforms {
+			env, err := platformEnv(platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkgs, err := load([]string{"./testdata/platforms"}, test.tags, env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pkg := range pkgs {
+				if _, err := fixPackage(pkg, done, options{list: true}, &out); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		var got []string
+		for _, filename := range strings.Fields(out.String()) {
+			got = append(got, filepath.Base(filename))
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%v %v: got changed files %v, want %v", test.platforms, test.tags, got, test.want)
+		}
+	}
+}
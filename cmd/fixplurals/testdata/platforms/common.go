@@ -0,0 +1,3 @@
+package platforms
+
+func common(a int, b int) {}
@@ -1,8 +1,10 @@
 module github.com/shiba6v/reftools
 
+require golang.org/x/tools v0.45.0
+
 require (
-	github.com/kisielk/gotool v1.0.0
-	golang.org/x/tools v0.0.0-20190408220357-e5b8258f4918
+	golang.org/x/mod v0.36.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
 )
 
-go 1.13
+go 1.25.0